@@ -6,26 +6,198 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 
+	"github.com/senseyman/auction-house/model"
 	"github.com/senseyman/auction-house/service/auction"
+	"github.com/senseyman/auction-house/service/currency"
+	"github.com/senseyman/auction-house/service/fee"
+	"github.com/senseyman/auction-house/service/ledger"
 	"github.com/senseyman/auction-house/service/reader"
 	"github.com/senseyman/auction-house/service/report"
+	"github.com/senseyman/auction-house/service/snapshot"
+	"github.com/senseyman/auction-house/service/user"
+	"github.com/senseyman/auction-house/service/wal"
+	"github.com/senseyman/auction-house/storage/archive"
+	"github.com/senseyman/auction-house/storage/bolt"
+	"github.com/senseyman/auction-house/storage/eventsourced"
 	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/sqlite"
+)
+
+// list of supported reports
+const (
+	reportAuction      = "auction"
+	reportSettlement   = "settlement"
+	reportTrialBalance = "trial-balance"
+	reportDeposit      = "deposit"
+)
+
+// cmdStateAt reports the state of one auction as of the time: state-at <item> <timestamp>
+const cmdStateAt = "state-at"
+
+// list of supported storages
+const (
+	storageMemory = "memory"
+	storageSQLite = "sqlite"
+	storageBolt   = "bolt"
+	storageEvents = "events"
 )
 
 var (
-	filePathFlag = flag.String("path", "input.txt", "")
+	filePathFlag         = flag.String("path", "input.txt", "")
+	retractionWindowFlag = flag.Int64("retraction-window", 10, "number of seconds a bidder has to retract the latest bid")
+	adminsFlag           = flag.String("admins", "", "comma separated list of users allowed to run privileged commands")
+	currencyFlag         = flag.String("currency", "USD", "the site's reporting currency")
+	ratesPathFlag        = flag.String("rates", "", "path to the exchange rate table file")
+	buyerPremiumFlag     = flag.String("buyer-premium", "0", "buyer's premium percent on the hammer price")
+	commissionFlag       = flag.String("seller-commission", "", "comma separated seller commission tiers price:percent, e.g. 0:10,1000:7.5")
+	usersPathFlag        = flag.String("users", "", "path to the user accounts file")
+	paymentDeadlineFlag  = flag.Int64("payment-deadline", 0, "number of seconds a buyer has to pay since the offer, 0 means no deadline")
+	maxWinsFlag          = flag.Int("max-wins", 0, "max number of items a bidder may win across the sale, 0 means no limit")
+	reportFlag           = flag.String("report", reportAuction, "report type: auction, settlement, trial-balance or deposit")
+	storageFlag          = flag.String("storage", storageMemory, "storage type: memory, sqlite, bolt or events")
+	dbPathFlag           = flag.String("db", "auction.db", "path to the database file of sqlite and bolt storages or the event file of the events storage")
+	walPathFlag          = flag.String("wal", "", "path to the write-ahead command log of the memory storage, empty means no log")
+	snapshotPathFlag     = flag.String("snapshot", "", "path to the snapshot file of the memory storage, requires the command log")
+	snapshotEveryFlag    = flag.Int("snapshot-every", 1000, "number of logged commands between snapshots")
+	archivePathFlag      = flag.String("archive", "", "path to the archive file closed auctions are moved to, empty means no archiving")
+	archiveAfterFlag     = flag.Int64("archive-after", 0, "number of seconds closed auctions are kept in memory before archiving")
 )
 
 func main() {
 	flag.Parse()
 
+	os.Exit(run())
+}
+
+// run runs the app and returns the exit code, so deferred calls are done before the exit
+func run() int {
+
+	stateAt, err := parseStateAt(flag.Args())
+	if err != nil {
+		fmt.Printf("invalid command: %v\n", err)
+		return 1
+	}
+
+	admins, err := parseUserIDs(*adminsFlag)
+	if err != nil {
+		fmt.Printf("invalid admins list: %v\n", err)
+		return 1
+	}
+
+	reportingCurrency, err := model.ParseCurrency(*currencyFlag)
+	if err != nil {
+		fmt.Printf("invalid reporting currency: %v\n", err)
+		return 1
+	}
+
+	buyerPremiumRate, err := model.ParsePercent(*buyerPremiumFlag)
+	if err != nil {
+		fmt.Printf("invalid buyer's premium: %v\n", err)
+		return 1
+	}
+	commissionTiers, err := fee.ParseTiers(*commissionFlag)
+	if err != nil {
+		fmt.Printf("invalid seller commission: %v\n", err)
+		return 1
+	}
+
 	// first - init all services
-	storage := inmemory.New()
+	currencyService := currency.New(reportingCurrency)
+	if *ratesPathFlag != "" {
+		if err = currencyService.Load(*ratesPathFlag); err != nil {
+			fmt.Printf("error while loading exchange rates: %v\n", err)
+			return 1
+		}
+	}
+	var userOpts []user.Option
+	if *usersPathFlag != "" {
+		// only registered users are eligible when the registry is loaded
+		userOpts = append(userOpts, user.WithUnknownUsersRejected())
+	}
+	userService := user.New(userOpts...)
+	if *usersPathFlag != "" {
+		if err = userService.Load(*usersPathFlag); err != nil {
+			fmt.Printf("error while loading user accounts: %v\n", err)
+			return 1
+		}
+	}
+	ledgerService := ledger.New()
+	storageOpts := []inmemory.Option{
+		inmemory.WithRetractionWindow(*retractionWindowFlag),
+		inmemory.WithCurrencyConverter(currencyService),
+		inmemory.WithFeeCalculator(fee.New(buyerPremiumRate, commissionTiers)),
+		inmemory.WithCreditLimits(userService),
+		inmemory.WithPaymentDeadline(*paymentDeadlineFlag),
+		inmemory.WithLedger(ledgerService),
+		inmemory.WithMaxWinsPerBidder(*maxWinsFlag),
+	}
+	if *archivePathFlag != "" {
+		archiveFile, err := archive.NewFile(*archivePathFlag)
+		if err != nil {
+			fmt.Printf("error while opening archive: %v\n", err)
+			return 1
+		}
+		defer archiveFile.Close()
+		storageOpts = append(storageOpts, inmemory.WithArchive(archiveFile, *archiveAfterFlag))
+	}
+	storage, closeStorage, err := newStorage(*storageFlag, *dbPathFlag, storageOpts)
+	if err != nil {
+		fmt.Printf("error while opening storage: %v\n", err)
+		return 1
+	}
+	defer closeStorage()
 	readService := reader.New()
-	reportService := report.New()
-	auctionService := auction.New(storage, readService, reportService)
+
+	var reportService auction.ReportService
+	switch {
+	case stateAt != nil:
+		reportService = report.NewAuctionState(storage, stateAt.item, stateAt.timestamp)
+	case *reportFlag == reportAuction:
+		reportService = report.New(report.WithCurrencyConverter(currencyService))
+	case *reportFlag == reportSettlement:
+		reportService = report.NewSettlement()
+	case *reportFlag == reportTrialBalance:
+		reportService = report.NewTrialBalance(ledgerService)
+	case *reportFlag == reportDeposit:
+		reportService = report.NewDeposit()
+	default:
+		fmt.Printf("unknown report type: %s\n", *reportFlag)
+		return 1
+	}
+
+	auctionOpts := []auction.Option{
+		auction.WithAdmins(admins...),
+		auction.WithUserService(userService),
+	}
+	if *walPathFlag != "" {
+		// persistent storages save every command themselves, replaying the log would apply commands twice
+		memStorage, ok := storage.(*inmemory.Storage)
+		if !ok {
+			fmt.Printf("command log is supported only by the %s storage\n", storageMemory)
+			return 1
+		}
+		walService, err := wal.New(*walPathFlag)
+		if err != nil {
+			fmt.Printf("error while opening command log: %v\n", err)
+			return 1
+		}
+		defer walService.Close()
+		auctionOpts = append(auctionOpts, auction.WithCommandLog(walService))
+
+		if *snapshotPathFlag != "" {
+			snapshotService := snapshot.New(*snapshotPathFlag, memStorage,
+				snapshot.WithLedger(ledgerService),
+				snapshot.WithUserService(userService),
+			)
+			auctionOpts = append(auctionOpts, auction.WithSnapshots(snapshotService, *snapshotEveryFlag))
+		}
+	}
+
+	auctionService := auction.New(storage, readService, reportService, auctionOpts...)
 
 	// create global context with cancel
 	ctx, cancel := context.WithCancel(context.Background())
@@ -38,7 +210,10 @@ func main() {
 	// run the main flow
 	if err := auctionService.Start(ctx, *filePathFlag); err != nil {
 		fmt.Printf("error while executing auction: %v\n", err)
+		return 1
 	}
+
+	return 0
 }
 
 func processErrMsgs(errCh chan error) {
@@ -47,6 +222,84 @@ func processErrMsgs(errCh chan error) {
 	}
 }
 
+// newStorage creates the storage of the given type and returns the function closing it
+func newStorage(storageType, dbPath string, opts []inmemory.Option) (auction.Storage, func() error, error) {
+	switch storageType {
+	case storageMemory:
+		return inmemory.New(opts...), func() error { return nil }, nil
+	case storageSQLite:
+		storage, err := sqlite.New(context.Background(), dbPath, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage, storage.Close, nil
+	case storageBolt:
+		storage, err := bolt.New(context.Background(), dbPath, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage, storage.Close, nil
+	case storageEvents:
+		store, err := eventsourced.NewFileStore(dbPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		storage, err := eventsourced.New(context.Background(), store, opts...)
+		if err != nil {
+			store.Close()
+			return nil, nil, err
+		}
+		return storage, store.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage type: %s", storageType)
+	}
+}
+
+// parseUserIDs parses comma separated list of user ids
+func parseUserIDs(list string) ([]int, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	elements := strings.Split(list, ",")
+	userIDs := make([]int, 0, len(elements))
+	for _, el := range elements {
+		userID, err := strconv.Atoi(strings.TrimSpace(el))
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, nil
+}
+
+// stateAtArgs are arguments of the state-at command
+type stateAtArgs struct {
+	item      string
+	timestamp int64
+}
+
+// parseStateAt parses the command after flags, no command means the report of auction results
+func parseStateAt(args []string) (*stateAtArgs, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if args[0] != cmdStateAt {
+		return nil, fmt.Errorf("unknown command: %s", args[0])
+	}
+	if len(args) != 3 {
+		return nil, fmt.Errorf("usage: %s <item> <timestamp>", cmdStateAt)
+	}
+
+	timestamp, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stateAtArgs{item: args[1], timestamp: timestamp}, nil
+}
+
 // setupGracefulShutdown provides processing income os signals and stopping the app by canceling global app context
 func setupGracefulShutdown(stop func()) {
 	signalChannel := make(chan os.Signal, 1)
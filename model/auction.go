@@ -1,26 +1,74 @@
 package model
 
-// OrderAction provides information about bids by users
+type OrderActionType int
+
+const (
+	OrderActionTypeInit OrderActionType = iota
+	OrderActionTypeBid
+	OrderActionTypeRetract
+	OrderActionTypeCancel
+	OrderActionTypeExtend
+	OrderActionTypeForceClose
+	OrderActionTypeVoid
+	OrderActionTypeReopen
+	OrderActionTypeReserveChange
+	OrderActionTypePayment
+	OrderActionTypeSecondChanceOffer
+	OrderActionTypeRefund
+	OrderActionTypeSettle
+	OrderActionTypeBidsDrop
+	OrderActionTypeDeposit
+)
+
+// OrderAction provides information about bids by users and other changes of the auction (audit trail)
 type OrderAction struct {
-	Order    *Order
-	UserID   int
-	BidValue float32
+	Order     *Order
+	Type      OrderActionType
+	Timestamp int64
+	UserID    int
+	BidValue  Money
+	Retracted bool // set for bids withdrawn by the bidder
+	Dropped   bool // set for bids of the bidder who has won the max number of items
+
+	NativeBidValue Money    // bid amount in the currency of the bidder, BidValue is converted to the currency of the order
+	Currency       Currency // currency of the bidder
+
+	ReservePrice Money // reserve price in effect since the action, set for init and reserve change actions
+	CloseTime    int64 // close time in effect since the action, set for init and audit actions
+
+	Outcome OfferStatus // outcome of the offer, set for payment actions
 }
 
 // ActionResult - auction result for an order
 type ActionResult struct {
 	CreationTime int64
+	StartTime    int64
 	CloseTime    int64
 	Item         string
+	Currency     Currency
+	SellerID     int
 	UserID       int
 	Status       OrderStatus
-	PricePaid    float32
-	Statistics   AuctionStatistics
+	PricePaid    Money
+	StartPrice   Money
+	ReserveMet   bool
+
+	OriginalReservePrice Money
+	FinalReservePrice    Money
+
+	BuyerPremium     Money
+	SellerCommission Money
+
+	Offers           []Offer
+	SettlementStatus SettlementStatus
+	Deposits         []Deposit
+
+	Statistics AuctionStatistics
 }
 
 // AuctionStatistics provides some helpful statistics about an order auction
 type AuctionStatistics struct {
 	TotalBidCount int
-	HighestBid    float32
-	LowestBid     float32
+	HighestBid    Money
+	LowestBid     Money
 }
@@ -7,14 +7,41 @@ const (
 	CommandTypeSell
 	CommandTypeBid
 	CommandTypeHeartbeat
+	CommandTypeCancel
+	CommandTypeRetract
+	CommandTypeAdmin
+	CommandTypeReserve
+	CommandTypePayment
+	CommandTypeDeposit
+)
+
+type AdminAction string
+
+const (
+	AdminActionExtend     AdminAction = "EXTEND"
+	AdminActionForceClose AdminAction = "CLOSE"
+	AdminActionVoid       AdminAction = "VOID"
+	AdminActionReopen     AdminAction = "REOPEN"
+	AdminActionActivate   AdminAction = "ACTIVATE"
+	AdminActionSuspend    AdminAction = "SUSPEND"
+	AdminActionBan        AdminAction = "BAN"
+	AdminActionRefund     AdminAction = "REFUND"
+	AdminActionSettle     AdminAction = "SETTLE"
 )
 
 // Command struct contains commands from input file for future processing
 type Command struct {
 	Type      CommandType
+	Seq       int64 // number of the line in the input file
 	Sell      *SellCommand
 	Bid       *BidCommand
 	Heartbeat *HeartbeatCommand
+	Cancel    *CancelCommand
+	Retract   *RetractCommand
+	Admin     *AdminCommand
+	Reserve   *ReserveCommand
+	Payment   *PaymentCommand
+	Deposit   *DepositCommand
 }
 
 // SellCommand provides sell instructions
@@ -22,8 +49,12 @@ type SellCommand struct {
 	Timestamp    int64
 	UserID       int
 	ItemName     string
-	ReservePrice float32
+	ReservePrice Money
 	CloseTime    int64
+	StartTime    int64 // optional time the auction starts accepting bids, 0 - start immediately
+	StartPrice   Money // optional minimal amount of a valid bid
+	Currency     Currency
+	DepositRate  int64 // optional deposit required from bidders in basis points of the reserve price
 }
 
 // BidCommand provides bid instructions
@@ -31,10 +62,59 @@ type BidCommand struct {
 	Timestamp int64
 	UserID    int
 	ItemName  string
-	BidAmount float32
+	BidAmount Money
+	Currency  Currency
 }
 
 // HeartbeatCommand provides heartbeat instructions
 type HeartbeatCommand struct {
 	Timestamp int64
 }
+
+// CancelCommand provides instructions for withdrawing an item by its seller
+type CancelCommand struct {
+	Timestamp int64
+	UserID    int
+	ItemName  string
+}
+
+// RetractCommand provides instructions for withdrawing the latest bid of a user
+type RetractCommand struct {
+	Timestamp int64
+	UserID    int
+	ItemName  string
+}
+
+// AdminCommand provides privileged instructions for fixing auction incidents and managing users
+type AdminCommand struct {
+	Timestamp    int64
+	UserID       int
+	Action       AdminAction
+	ItemName     string
+	CloseTime    int64 // new close time for EXTEND and REOPEN actions
+	TargetUserID int   // user for ACTIVATE, SUSPEND and BAN actions
+}
+
+// ReserveCommand provides instructions for lowering the reserve price by the seller
+type ReserveCommand struct {
+	Timestamp    int64
+	UserID       int
+	ItemName     string
+	ReservePrice Money
+}
+
+// PaymentCommand provides the payment outcome of the buyer after the auction close
+type PaymentCommand struct {
+	Timestamp int64
+	UserID    int
+	ItemName  string
+	Outcome   OfferStatus // PAID or DEFAULTED
+}
+
+// DepositCommand provides the deposit posted by the bidder to bid on the item
+type DepositCommand struct {
+	Timestamp int64
+	UserID    int
+	ItemName  string
+	Amount    Money // in the currency of the item
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency is ISO 4217 currency code like "USD". Empty currency means the site's reporting currency.
+type Currency string
+
+// ParseCurrency parses and normalizes three letters currency code
+func ParseCurrency(str string) (Currency, error) {
+	str = strings.ToUpper(strings.TrimSpace(str))
+	if len(str) != 3 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, str)
+	}
+	for _, ch := range str {
+		if ch < 'A' || ch > 'Z' {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, str)
+		}
+	}
+
+	return Currency(str), nil
+}
@@ -8,6 +8,33 @@ import (
 var (
 	ErrUnknownCommandType      = errors.New("unknown command type")
 	ErrInvalidData             = errors.New("invalid data")
+	ErrInvalidMoney            = errors.New("invalid money amount")
+	ErrInvalidCurrency         = errors.New("invalid currency")
+	ErrUnsupportedCurrency     = errors.New("unsupported currency")
+	ErrCreditLimitExceeded     = errors.New("credit limit is exceeded")
+	ErrUnknownUser             = errors.New("unknown user")
+	ErrUserSuspended           = errors.New("user is suspended")
+	ErrUserBanned              = errors.New("user is banned")
+	ErrUserRoleNotAllowed      = errors.New("user role doesn't allow the action")
 	ErrNotFound                = errors.New("not found")
 	ErrAuctionIsFinishedByTime = errors.New("auction is finished by time")
+	ErrAuctionIsNotStarted     = errors.New("auction is not started yet")
+	ErrBidIsTooLow             = errors.New("bid is lower than the starting price")
+	ErrReserveCanNotBeRaised   = errors.New("reserve price can not be raised")
+	ErrAuctionIsClosed         = errors.New("auction is closed")
+	ErrNotSeller               = errors.New("user is not the seller of the item")
+	ErrReserveIsMet            = errors.New("reserve price is met")
+	ErrBidNotFound             = errors.New("bid not found")
+	ErrRetractionWindowExpired = errors.New("retraction window is expired")
+	ErrPermissionDenied        = errors.New("permission denied")
+	ErrAuctionIsOpen           = errors.New("auction is open")
+	ErrAuctionIsCancelled      = errors.New("auction is cancelled by the seller")
+	ErrOrderIsNotSold          = errors.New("order is not sold")
+	ErrNotBuyer                = errors.New("user is not the buyer of the item")
+	ErrOfferIsNotPending       = errors.New("offer is not pending")
+	ErrInvalidSettlementStatus = errors.New("action is not allowed in the settlement status")
+	ErrUnbalancedEntry         = errors.New("ledger entry is unbalanced")
+	ErrLedgerMismatch          = errors.New("ledger doesn't reconcile with the auction result")
+	ErrMaxWinsReached          = errors.New("bidder has won the max number of items")
+	ErrDepositRequired         = errors.New("deposit doesn't cover the required percentage of the reserve price")
 )
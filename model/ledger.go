@@ -0,0 +1,33 @@
+package model
+
+type LedgerEventType string
+
+const (
+	LedgerEventSale         LedgerEventType = "SALE"          // the item is offered to the buyer at close or by the second chance
+	LedgerEventSaleReversal LedgerEventType = "SALE_REVERSAL" // the unpaid sale is defaulted, voided or reopened
+	LedgerEventPayment      LedgerEventType = "PAYMENT"       // the buyer pays to the escrow
+	LedgerEventSettlement   LedgerEventType = "SETTLEMENT"    // the escrow is paid out to the seller and the auction house
+	LedgerEventRefund       LedgerEventType = "REFUND"        // the escrow is returned to the buyer
+)
+
+// LedgerEvent provides the money movement of the sold order to be posted to the ledger.
+// Amounts are in the currency of the order
+type LedgerEvent struct {
+	Type             LedgerEventType
+	Timestamp        int64
+	Item             string
+	Currency         Currency
+	SellerID         int
+	BuyerID          int
+	Price            Money
+	BuyerPremium     Money
+	SellerCommission Money
+}
+
+// LedgerBalance provides the balance of the ledger account in the currency
+type LedgerBalance struct {
+	Account  string
+	Currency Currency
+	Debit    Money
+	Credit   Money
+}
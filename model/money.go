@@ -0,0 +1,106 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Money is a fixed-point amount of money in cents.
+// It keeps amounts exact, so comparisons and arithmetic don't suffer from float rounding.
+type Money int64
+
+// MaxMoney is the largest amount of money
+const MaxMoney = Money(math.MaxInt64)
+
+// centsInUnit is the number of cents in one currency unit
+const centsInUnit = 100
+
+// ParseMoney parses decimal string like "1234567.89" to Money without loss of precision.
+// The amount can't have more than two significant decimal places.
+func ParseMoney(str string) (Money, error) {
+	str = strings.TrimSpace(str)
+
+	negative := false
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		negative = str[0] == '-'
+		str = str[1:]
+	}
+
+	units, fraction, _ := strings.Cut(str, ".")
+	if units == "" && fraction == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, str)
+	}
+
+	// drop insignificant trailing zeros, e.g. "10.500"
+	if len(fraction) > 2 {
+		if strings.Trim(fraction[2:], "0") != "" {
+			return 0, fmt.Errorf("%w: %q has more than two decimal places", ErrInvalidMoney, str)
+		}
+		fraction = fraction[:2]
+	}
+	// pad fraction to cents, e.g. "10.5"
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	var cents int64
+	for _, ch := range units + fraction {
+		if ch < '0' || ch > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, str)
+		}
+		if cents > (math.MaxInt64-int64(ch-'0'))/10 {
+			return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, str)
+		}
+		cents = cents*10 + int64(ch-'0')
+	}
+
+	if negative {
+		cents = -cents
+	}
+
+	return Money(cents), nil
+}
+
+// Cents returns the amount in cents
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// MulDiv returns the amount multiplied by num/den and rounded half away from zero to cents.
+// It is used for exchange rates and percentages without intermediate overflow,
+// the result out of the Money range is saturated to MaxMoney or -MaxMoney.
+func (m Money) MulDiv(num, den int64) Money {
+	res := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(num))
+	denominator := big.NewInt(den)
+	if denominator.Sign() < 0 {
+		res.Neg(res)
+		denominator.Neg(denominator)
+	}
+
+	quo, rem := new(big.Int).QuoRem(res, denominator, new(big.Int))
+	// round half away from zero
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(denominator) >= 0 {
+		quo.Add(quo, big.NewInt(int64(res.Sign())))
+	}
+
+	switch {
+	case quo.Cmp(big.NewInt(int64(MaxMoney))) > 0:
+		return MaxMoney
+	case quo.Cmp(big.NewInt(-int64(MaxMoney))) < 0:
+		return -MaxMoney
+	default:
+		return Money(quo.Int64())
+	}
+}
+
+// String formats the amount with two decimal places, e.g. "1234567.89"
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, cents/centsInUnit, cents%centsInUnit)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		exp    Money
+		hasErr bool
+	}{
+		{name: "success/integer", input: "10", exp: 1000},
+		{name: "success/two_decimals", input: "12.50", exp: 1250},
+		{name: "success/one_decimal", input: "7.5", exp: 750},
+		{name: "success/no_units", input: ".99", exp: 99},
+		{name: "success/trailing_zeros", input: "10.5000", exp: 1050},
+		{name: "success/large", input: "1234567.89", exp: 123456789},
+		{name: "success/negative", input: "-0.01", exp: -1},
+		{name: "success/spaces", input: " 3.10 ", exp: 310},
+		{name: "err/empty", input: "", hasErr: true},
+		{name: "err/dot", input: ".", hasErr: true},
+		{name: "err/three_decimals", input: "10.005", hasErr: true},
+		{name: "err/not_number", input: "1a.00", hasErr: true},
+		{name: "err/two_dots", input: "1.0.0", hasErr: true},
+		{name: "err/overflow", input: "92233720368547758.08", hasErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := ParseMoney(tc.input)
+
+			if tc.hasErr {
+				assert.ErrorIs(t, err, ErrInvalidMoney)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.exp, res)
+			}
+		})
+	}
+}
+
+func TestMoney_MulDiv(t *testing.T) {
+	assert.Equal(t, Money(1085), Money(1000).MulDiv(1085000, 1000000))
+	assert.Equal(t, Money(3), Money(5).MulDiv(1, 2))   // 2.5 cents rounded up
+	assert.Equal(t, Money(-3), Money(-5).MulDiv(1, 2)) // -2.5 cents rounded down
+	assert.Equal(t, Money(2), Money(5).MulDiv(4, 10))
+	assert.Equal(t, Money(123456789), Money(123456789).MulDiv(7, 7))
+
+	// results out of range are saturated instead of wrapping around
+	assert.Equal(t, MaxMoney, MaxMoney.MulDiv(3, 2))
+	assert.Equal(t, -MaxMoney, MaxMoney.MulDiv(-3, 2))
+	assert.Equal(t, -MaxMoney, Money(-5).MulDiv(math.MaxInt64, 1))
+	assert.Equal(t, MaxMoney, MaxMoney.MulDiv(math.MaxInt64, math.MaxInt64))
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "0.00", Money(0).String())
+	assert.Equal(t, "0.07", Money(7).String())
+	assert.Equal(t, "12.50", Money(1250).String())
+	assert.Equal(t, "1234567.89", Money(123456789).String())
+	assert.Equal(t, "-1.05", Money(-105).String())
+}
@@ -3,23 +3,87 @@ package model
 type OrderStatus string
 
 const (
-	OrderStatusInit   OrderStatus = "INIT"
-	OrderStatusSold   OrderStatus = "SOLD"
-	OrderStatusUnsold OrderStatus = "UNSOLD"
+	OrderStatusInit      OrderStatus = "INIT"
+	OrderStatusSold      OrderStatus = "SOLD"
+	OrderStatusUnsold    OrderStatus = "UNSOLD"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+	OrderStatusVoided    OrderStatus = "VOIDED"
 )
 
+// SettlementStatus is the post-auction lifecycle state of the sold order
+type SettlementStatus string
+
+const (
+	SettlementStatusAwaitingPayment SettlementStatus = "AWAITING_PAYMENT"
+	SettlementStatusPaid            SettlementStatus = "PAID"
+	SettlementStatusDefaulted       SettlementStatus = "DEFAULTED"
+	SettlementStatusRefunded        SettlementStatus = "REFUNDED"
+	SettlementStatusSettled         SettlementStatus = "SETTLED"
+)
+
+type OfferStatus string
+
+const (
+	OfferStatusPending   OfferStatus = "PENDING"
+	OfferStatusPaid      OfferStatus = "PAID"
+	OfferStatusDefaulted OfferStatus = "DEFAULTED"
+)
+
+// Offer provides the offer to buy the sold item.
+// The first offer is made to the winner, the next ones are second-chance offers to other bidders.
+type Offer struct {
+	UserID    int
+	Price     Money
+	Timestamp int64
+	Deadline  int64 // the last time to pay, 0 means no deadline
+	Status    OfferStatus
+}
+
+type DepositStatus string
+
+const (
+	DepositStatusHeld      DepositStatus = "HELD"
+	DepositStatusRefunded  DepositStatus = "REFUNDED"
+	DepositStatusForfeited DepositStatus = "FORFEITED"
+)
+
+// Deposit provides the deposit posted by the bidder to bid on the item
+type Deposit struct {
+	UserID       int
+	Amount       Money // in the currency of the item
+	Timestamp    int64
+	Status       DepositStatus
+	ResolvedTime int64 // time the deposit is refunded or forfeited
+}
+
 // Item provides base information for an item we put to the auction
 type Item struct {
 	Name         string
-	ReservePrice float32
+	StartPrice   Money // published minimal bid
+	ReservePrice Money // hidden minimal price the item can be sold for
+	Currency     Currency
+	DepositRate  int64 // deposit required from bidders in basis points of the reserve price, 0 - not required
 }
 
 // Order provides auction order information
 type Order struct {
 	Item         Item
+	SellerID     int
 	CreationTime int64
+	StartTime    int64
 	Status       OrderStatus
 	CloseTime    int64
-	LastBid      float32
-	CloseBid     float32
+	LastBid      Money
+	CloseBid     Money
+	ReserveMet   bool
+	WinnerID     int     // buyer of the sold item
+	Offers       []Offer // chain of offers to buy the sold item, the last one is the current
+
+	OriginalReservePrice Money // reserve price at the listing, the item has the reserve price in effect
+
+	SettlementStatus SettlementStatus
+	Deposits         []Deposit // deposits of bidders, one per user
+
+	BuyerPremium     Money // fee paid by the winner on top of the close bid
+	SellerCommission Money // fee withheld from the close bid paid to the seller
 }
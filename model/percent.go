@@ -0,0 +1,25 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// BasisPointsInUnit is the number of basis points in 100%
+const BasisPointsInUnit = 10000
+
+// ParsePercent parses percent like "7.5" to basis points
+func ParsePercent(str string) (int64, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(str))
+	if !ok || value.Sign() < 0 {
+		return 0, fmt.Errorf("%w: percent %q", ErrInvalidData, str)
+	}
+
+	value.Mul(value, big.NewRat(BasisPointsInUnit/100, 1))
+	if !value.IsInt() || !value.Num().IsInt64() {
+		return 0, fmt.Errorf("%w: percent %q has more than two decimal places", ErrInvalidData, str)
+	}
+
+	return value.Num().Int64(), nil
+}
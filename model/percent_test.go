@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePercent(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		exp    int64
+		hasErr bool
+	}{
+		{name: "success/integer", input: "10", exp: 1000},
+		{name: "success/decimals", input: "7.25", exp: 725},
+		{name: "success/spaces", input: " 0.5 ", exp: 50},
+		{name: "err/empty", input: "", hasErr: true},
+		{name: "err/negative", input: "-1", hasErr: true},
+		{name: "err/three_decimals", input: "7.125", hasErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := ParsePercent(tc.input)
+
+			if tc.hasErr {
+				assert.ErrorIs(t, err, ErrInvalidData)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.exp, res)
+			}
+		})
+	}
+}
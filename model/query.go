@@ -0,0 +1,38 @@
+package model
+
+// AuctionView provides the order with its current leader and price
+type AuctionView struct {
+	Order      Order
+	LeaderID   int   // bidder of the leading bid, 0 if there are no bids
+	Price      Money // the leading bid of the open order, the close bid of the closed one
+	Statistics AuctionStatistics
+}
+
+// AuctionFilter selects open auctions, zero fields match all auctions
+type AuctionFilter struct {
+	ClosingBefore int64 // the auction closes earlier than the time
+	SellerID      int
+	ReserveMet    *bool // the leading bid reaches the reserve price or not
+}
+
+// Page selects the part of the list
+type Page struct {
+	Offset int
+	Limit  int // 0 means no limit
+}
+
+// BidHistory provides the page of bids on the order in the order they were placed
+type BidHistory struct {
+	Bids  []OrderAction // retracted and dropped bids are marked, the Order field is not set
+	Total int           // number of all bids on the order
+}
+
+// AuctionState provides the state of the auction as of the time
+type AuctionState struct {
+	Timestamp int64
+	Item      string
+	Status    OrderStatus
+	LeaderID  int   // the leading bidder of the open auction, the buyer of the sold one
+	Price     Money // the leading bid of the open auction, the price of the sold one
+	BidCount  int   // number of active bids
+}
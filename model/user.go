@@ -0,0 +1,36 @@
+package model
+
+type UserStatus string
+
+const (
+	UserStatusActive    UserStatus = "ACTIVE"
+	UserStatusSuspended UserStatus = "SUSPENDED" // temporary restriction, can be activated again
+	UserStatusBanned    UserStatus = "BANNED"    // permanent restriction
+)
+
+type UserRole string
+
+const (
+	UserRoleBuyer  UserRole = "BUYER"
+	UserRoleSeller UserRole = "SELLER"
+)
+
+// User provides user account information
+type User struct {
+	ID             int
+	Status         UserStatus
+	Roles          []UserRole
+	CreditLimit    Money // max sum of leading bids across open auctions in the reporting currency
+	HasCreditLimit bool  // user without credit limit can bid without restrictions
+}
+
+// HasRole checks the user has the role
+func (u *User) HasRole(role UserRole) bool {
+	for _, el := range u.Roles {
+		if el == role {
+			return true
+		}
+	}
+
+	return false
+}
@@ -9,15 +9,50 @@ import (
 type Storage interface {
 	CreateOrder(ctx context.Context, order model.Order) error
 	BidOrder(ctx context.Context, bid model.BidCommand) error
+	PostDeposit(ctx context.Context, deposit model.DepositCommand) error
+	CancelOrder(ctx context.Context, cancel model.CancelCommand) error
+	RetractBid(ctx context.Context, retract model.RetractCommand) error
+	LowerReserve(ctx context.Context, reserve model.ReserveCommand) error
+	RecordPayment(ctx context.Context, payment model.PaymentCommand) error
+	RefundOrder(ctx context.Context, cmd model.AdminCommand) error
+	SettleOrder(ctx context.Context, cmd model.AdminCommand) error
+	ExtendAuction(ctx context.Context, cmd model.AdminCommand) error
+	ForceCloseAuction(ctx context.Context, cmd model.AdminCommand) error
+	VoidAuction(ctx context.Context, cmd model.AdminCommand) error
+	ReopenAuction(ctx context.Context, cmd model.AdminCommand) error
 	FinishExpiredAuctions(ctx context.Context, timestamp int64) error
 	FinishAllAuctions(_ context.Context) error
 	GetAuctionResults(ctx context.Context) ([]model.ActionResult, error)
+
+	GetOrder(ctx context.Context, itemName string) (model.AuctionView, error)
+	ListOpenAuctions(ctx context.Context, filter model.AuctionFilter) ([]model.AuctionView, error)
+	GetBidHistory(ctx context.Context, itemName string, page model.Page) (model.BidHistory, error)
+	GetOrderAt(ctx context.Context, itemName string, timestamp int64) (model.AuctionState, error)
 }
 
 type ReadService interface {
 	Read(filename string, outputCh chan model.Command) error
 }
 
+type UserService interface {
+	CheckSeller(userID int) error
+	CheckBuyer(userID int) error
+	SetStatus(userID int, status model.UserStatus) error
+}
+
+// CommandLog keeps accepted commands until they are saved in the snapshot
+type CommandLog interface {
+	Append(cmd model.Command) error
+	Replay(after int64, fn func(cmd model.Command) error) error
+	Truncate() error
+}
+
+// Snapshotter saves and restores the state of services after processing the command with the sequence number
+type Snapshotter interface {
+	Save(seq int64) error
+	Load() (int64, error)
+}
+
 type ReportService interface {
 	Report(fos []model.ActionResult) error
 }
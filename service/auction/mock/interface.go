@@ -54,6 +54,20 @@ func (mr *MockStorageMockRecorder) BidOrder(ctx, bid any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BidOrder", reflect.TypeOf((*MockStorage)(nil).BidOrder), ctx, bid)
 }
 
+// CancelOrder mocks base method.
+func (m *MockStorage) CancelOrder(ctx context.Context, cancel model.CancelCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelOrder", ctx, cancel)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelOrder indicates an expected call of CancelOrder.
+func (mr *MockStorageMockRecorder) CancelOrder(ctx, cancel any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockStorage)(nil).CancelOrder), ctx, cancel)
+}
+
 // CreateOrder mocks base method.
 func (m *MockStorage) CreateOrder(ctx context.Context, order model.Order) error {
 	m.ctrl.T.Helper()
@@ -68,6 +82,20 @@ func (mr *MockStorageMockRecorder) CreateOrder(ctx, order any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockStorage)(nil).CreateOrder), ctx, order)
 }
 
+// ExtendAuction mocks base method.
+func (m *MockStorage) ExtendAuction(ctx context.Context, cmd model.AdminCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtendAuction", ctx, cmd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExtendAuction indicates an expected call of ExtendAuction.
+func (mr *MockStorageMockRecorder) ExtendAuction(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtendAuction", reflect.TypeOf((*MockStorage)(nil).ExtendAuction), ctx, cmd)
+}
+
 // FinishAllAuctions mocks base method.
 func (m *MockStorage) FinishAllAuctions(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -96,6 +124,20 @@ func (mr *MockStorageMockRecorder) FinishExpiredAuctions(ctx, timestamp any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishExpiredAuctions", reflect.TypeOf((*MockStorage)(nil).FinishExpiredAuctions), ctx, timestamp)
 }
 
+// ForceCloseAuction mocks base method.
+func (m *MockStorage) ForceCloseAuction(ctx context.Context, cmd model.AdminCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceCloseAuction", ctx, cmd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceCloseAuction indicates an expected call of ForceCloseAuction.
+func (mr *MockStorageMockRecorder) ForceCloseAuction(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceCloseAuction", reflect.TypeOf((*MockStorage)(nil).ForceCloseAuction), ctx, cmd)
+}
+
 // GetAuctionResults mocks base method.
 func (m *MockStorage) GetAuctionResults(ctx context.Context) ([]model.ActionResult, error) {
 	m.ctrl.T.Helper()
@@ -111,6 +153,178 @@ func (mr *MockStorageMockRecorder) GetAuctionResults(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuctionResults", reflect.TypeOf((*MockStorage)(nil).GetAuctionResults), ctx)
 }
 
+// GetBidHistory mocks base method.
+func (m *MockStorage) GetBidHistory(ctx context.Context, itemName string, page model.Page) (model.BidHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidHistory", ctx, itemName, page)
+	ret0, _ := ret[0].(model.BidHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBidHistory indicates an expected call of GetBidHistory.
+func (mr *MockStorageMockRecorder) GetBidHistory(ctx, itemName, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidHistory", reflect.TypeOf((*MockStorage)(nil).GetBidHistory), ctx, itemName, page)
+}
+
+// GetOrder mocks base method.
+func (m *MockStorage) GetOrder(ctx context.Context, itemName string) (model.AuctionView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, itemName)
+	ret0, _ := ret[0].(model.AuctionView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockStorageMockRecorder) GetOrder(ctx, itemName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockStorage)(nil).GetOrder), ctx, itemName)
+}
+
+// GetOrderAt mocks base method.
+func (m *MockStorage) GetOrderAt(ctx context.Context, itemName string, timestamp int64) (model.AuctionState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderAt", ctx, itemName, timestamp)
+	ret0, _ := ret[0].(model.AuctionState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderAt indicates an expected call of GetOrderAt.
+func (mr *MockStorageMockRecorder) GetOrderAt(ctx, itemName, timestamp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderAt", reflect.TypeOf((*MockStorage)(nil).GetOrderAt), ctx, itemName, timestamp)
+}
+
+// ListOpenAuctions mocks base method.
+func (m *MockStorage) ListOpenAuctions(ctx context.Context, filter model.AuctionFilter) ([]model.AuctionView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenAuctions", ctx, filter)
+	ret0, _ := ret[0].([]model.AuctionView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenAuctions indicates an expected call of ListOpenAuctions.
+func (mr *MockStorageMockRecorder) ListOpenAuctions(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenAuctions", reflect.TypeOf((*MockStorage)(nil).ListOpenAuctions), ctx, filter)
+}
+
+// LowerReserve mocks base method.
+func (m *MockStorage) LowerReserve(ctx context.Context, reserve model.ReserveCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LowerReserve", ctx, reserve)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LowerReserve indicates an expected call of LowerReserve.
+func (mr *MockStorageMockRecorder) LowerReserve(ctx, reserve any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LowerReserve", reflect.TypeOf((*MockStorage)(nil).LowerReserve), ctx, reserve)
+}
+
+// PostDeposit mocks base method.
+func (m *MockStorage) PostDeposit(ctx context.Context, deposit model.DepositCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostDeposit", ctx, deposit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PostDeposit indicates an expected call of PostDeposit.
+func (mr *MockStorageMockRecorder) PostDeposit(ctx, deposit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostDeposit", reflect.TypeOf((*MockStorage)(nil).PostDeposit), ctx, deposit)
+}
+
+// RecordPayment mocks base method.
+func (m *MockStorage) RecordPayment(ctx context.Context, payment model.PaymentCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPayment", ctx, payment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordPayment indicates an expected call of RecordPayment.
+func (mr *MockStorageMockRecorder) RecordPayment(ctx, payment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPayment", reflect.TypeOf((*MockStorage)(nil).RecordPayment), ctx, payment)
+}
+
+// RefundOrder mocks base method.
+func (m *MockStorage) RefundOrder(ctx context.Context, cmd model.AdminCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundOrder", ctx, cmd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefundOrder indicates an expected call of RefundOrder.
+func (mr *MockStorageMockRecorder) RefundOrder(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundOrder", reflect.TypeOf((*MockStorage)(nil).RefundOrder), ctx, cmd)
+}
+
+// ReopenAuction mocks base method.
+func (m *MockStorage) ReopenAuction(ctx context.Context, cmd model.AdminCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReopenAuction", ctx, cmd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReopenAuction indicates an expected call of ReopenAuction.
+func (mr *MockStorageMockRecorder) ReopenAuction(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReopenAuction", reflect.TypeOf((*MockStorage)(nil).ReopenAuction), ctx, cmd)
+}
+
+// RetractBid mocks base method.
+func (m *MockStorage) RetractBid(ctx context.Context, retract model.RetractCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetractBid", ctx, retract)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetractBid indicates an expected call of RetractBid.
+func (mr *MockStorageMockRecorder) RetractBid(ctx, retract any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetractBid", reflect.TypeOf((*MockStorage)(nil).RetractBid), ctx, retract)
+}
+
+// SettleOrder mocks base method.
+func (m *MockStorage) SettleOrder(ctx context.Context, cmd model.AdminCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SettleOrder", ctx, cmd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SettleOrder indicates an expected call of SettleOrder.
+func (mr *MockStorageMockRecorder) SettleOrder(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SettleOrder", reflect.TypeOf((*MockStorage)(nil).SettleOrder), ctx, cmd)
+}
+
+// VoidAuction mocks base method.
+func (m *MockStorage) VoidAuction(ctx context.Context, cmd model.AdminCommand) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidAuction", ctx, cmd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VoidAuction indicates an expected call of VoidAuction.
+func (mr *MockStorageMockRecorder) VoidAuction(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidAuction", reflect.TypeOf((*MockStorage)(nil).VoidAuction), ctx, cmd)
+}
+
 // MockReadService is a mock of ReadService interface.
 type MockReadService struct {
 	ctrl     *gomock.Controller
@@ -148,6 +362,188 @@ func (mr *MockReadServiceMockRecorder) Read(filename, outputCh any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockReadService)(nil).Read), filename, outputCh)
 }
 
+// MockUserService is a mock of UserService interface.
+type MockUserService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserServiceMockRecorder
+}
+
+// MockUserServiceMockRecorder is the mock recorder for MockUserService.
+type MockUserServiceMockRecorder struct {
+	mock *MockUserService
+}
+
+// NewMockUserService creates a new mock instance.
+func NewMockUserService(ctrl *gomock.Controller) *MockUserService {
+	mock := &MockUserService{ctrl: ctrl}
+	mock.recorder = &MockUserServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckBuyer mocks base method.
+func (m *MockUserService) CheckBuyer(userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckBuyer", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckBuyer indicates an expected call of CheckBuyer.
+func (mr *MockUserServiceMockRecorder) CheckBuyer(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckBuyer", reflect.TypeOf((*MockUserService)(nil).CheckBuyer), userID)
+}
+
+// CheckSeller mocks base method.
+func (m *MockUserService) CheckSeller(userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckSeller", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckSeller indicates an expected call of CheckSeller.
+func (mr *MockUserServiceMockRecorder) CheckSeller(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSeller", reflect.TypeOf((*MockUserService)(nil).CheckSeller), userID)
+}
+
+// SetStatus mocks base method.
+func (m *MockUserService) SetStatus(userID int, status model.UserStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", userID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockUserServiceMockRecorder) SetStatus(userID, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockUserService)(nil).SetStatus), userID, status)
+}
+
+// MockCommandLog is a mock of CommandLog interface.
+type MockCommandLog struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandLogMockRecorder
+}
+
+// MockCommandLogMockRecorder is the mock recorder for MockCommandLog.
+type MockCommandLogMockRecorder struct {
+	mock *MockCommandLog
+}
+
+// NewMockCommandLog creates a new mock instance.
+func NewMockCommandLog(ctrl *gomock.Controller) *MockCommandLog {
+	mock := &MockCommandLog{ctrl: ctrl}
+	mock.recorder = &MockCommandLogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandLog) EXPECT() *MockCommandLogMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockCommandLog) Append(cmd model.Command) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", cmd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockCommandLogMockRecorder) Append(cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockCommandLog)(nil).Append), cmd)
+}
+
+// Replay mocks base method.
+func (m *MockCommandLog) Replay(after int64, fn func(model.Command) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", after, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockCommandLogMockRecorder) Replay(after, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockCommandLog)(nil).Replay), after, fn)
+}
+
+// Truncate mocks base method.
+func (m *MockCommandLog) Truncate() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Truncate")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Truncate indicates an expected call of Truncate.
+func (mr *MockCommandLogMockRecorder) Truncate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Truncate", reflect.TypeOf((*MockCommandLog)(nil).Truncate))
+}
+
+// MockSnapshotter is a mock of Snapshotter interface.
+type MockSnapshotter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotterMockRecorder
+}
+
+// MockSnapshotterMockRecorder is the mock recorder for MockSnapshotter.
+type MockSnapshotterMockRecorder struct {
+	mock *MockSnapshotter
+}
+
+// NewMockSnapshotter creates a new mock instance.
+func NewMockSnapshotter(ctrl *gomock.Controller) *MockSnapshotter {
+	mock := &MockSnapshotter{ctrl: ctrl}
+	mock.recorder = &MockSnapshotterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSnapshotter) EXPECT() *MockSnapshotterMockRecorder {
+	return m.recorder
+}
+
+// Load mocks base method.
+func (m *MockSnapshotter) Load() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockSnapshotterMockRecorder) Load() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockSnapshotter)(nil).Load))
+}
+
+// Save mocks base method.
+func (m *MockSnapshotter) Save(seq int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", seq)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockSnapshotterMockRecorder) Save(seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockSnapshotter)(nil).Save), seq)
+}
+
 // MockReportService is a mock of ReportService interface.
 type MockReportService struct {
 	ctrl     *gomock.Controller
@@ -15,16 +15,67 @@ type Service struct {
 
 	errCh     chan error
 	commandCh chan model.Command // channel for forwarding commands from file to processing flow
+
+	admins      map[int]struct{} // users allowed to run privileged commands
+	userService UserService      // optional registry checking users eligibility
+
+	commandLog    CommandLog  // optional log of commands written before they are applied
+	snapshotter   Snapshotter // optional snapshots of the state
+	snapshotEvery int         // number of logged commands between snapshots
+	lastSeq       int64       // sequence number of the last applied command
+	sinceSnapshot int         // number of commands logged since the last snapshot
+}
+
+// Option configures optional parameters of the service
+type Option func(s *Service)
+
+// WithAdmins sets users allowed to run privileged commands
+func WithAdmins(userIDs ...int) Option {
+	return func(s *Service) {
+		for _, userID := range userIDs {
+			s.admins[userID] = struct{}{}
+		}
+	}
 }
 
-func New(storage Storage, readService ReadService, reportService ReportService) *Service {
-	return &Service{
+// WithUserService sets the registry checking users eligibility before listing items and bidding
+func WithUserService(userService UserService) Option {
+	return func(s *Service) {
+		s.userService = userService
+	}
+}
+
+// WithCommandLog sets the log of accepted commands written before they are applied to the storage.
+// On start the log is replayed and commands of the input file applied before the restart are skipped
+func WithCommandLog(commandLog CommandLog) Option {
+	return func(s *Service) {
+		s.commandLog = commandLog
+	}
+}
+
+// WithSnapshots sets the snapshotter saving the state every given number of logged commands.
+// The command log is truncated after the snapshot is saved
+func WithSnapshots(snapshotter Snapshotter, every int) Option {
+	return func(s *Service) {
+		s.snapshotter = snapshotter
+		s.snapshotEvery = every
+	}
+}
+
+func New(storage Storage, readService ReadService, reportService ReportService, opts ...Option) *Service {
+	s := &Service{
 		storage:       storage,
 		readService:   readService,
 		reportService: reportService,
 		errCh:         make(chan error, 10),
 		commandCh:     make(chan model.Command),
+		admins:        make(map[int]struct{}),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // GetErrChannel returns error channel for processing errors on the app top level
@@ -39,6 +90,11 @@ func (s *Service) GetErrChannel() chan error {
 func (s *Service) Start(ctx context.Context, filename string) error {
 	var wg = &sync.WaitGroup{}
 
+	// restore the state saved before the restart
+	if err := s.recover(ctx); err != nil {
+		return err
+	}
+
 	// run processing commands
 	s.run(ctx, wg, s.commandCh)
 
@@ -85,22 +141,104 @@ func (s *Service) run(ctx context.Context, wg *sync.WaitGroup,
 	}()
 }
 
-// processCommand manages command types and calls the appropriate method
+// recover loads the snapshot and replays commands logged after it
+func (s *Service) recover(ctx context.Context) error {
+	if s.snapshotter != nil {
+		seq, err := s.snapshotter.Load()
+		if err != nil {
+			return err
+		}
+		s.lastSeq = seq
+	}
+
+	if s.commandLog == nil {
+		return nil
+	}
+
+	return s.commandLog.Replay(s.lastSeq, func(cmd model.Command) error {
+		// errors were already reported before the restart
+		_ = s.applyCommand(ctx, cmd)
+		s.lastSeq = cmd.Seq
+		return nil
+	})
+}
+
+// processCommand logs the command and applies it
 func (s *Service) processCommand(ctx context.Context, cmd model.Command) {
-	var err error
+	if s.commandLog != nil {
+		if cmd.Seq <= s.lastSeq {
+			// the command was applied before the restart
+			return
+		}
+		if err := s.logCommand(cmd); err != nil {
+			s.errCh <- err
+			return
+		}
+	}
+
+	if err := s.applyCommand(ctx, cmd); err != nil {
+		s.errCh <- err
+	}
+
+	if err := s.saveSnapshot(); err != nil {
+		s.errCh <- err
+	}
+}
+
+// logCommand writes the accepted command to the command log
+func (s *Service) logCommand(cmd model.Command) error {
+	s.lastSeq = cmd.Seq
+	if cmd.Type == model.CommandTypeUnknown {
+		return nil
+	}
+	if err := s.commandLog.Append(cmd); err != nil {
+		return err
+	}
+	s.sinceSnapshot++
+
+	return nil
+}
+
+// saveSnapshot saves the snapshot when enough commands are logged since the last one and truncates the command log
+func (s *Service) saveSnapshot() error {
+	if s.snapshotter == nil || s.snapshotEvery <= 0 || s.sinceSnapshot < s.snapshotEvery {
+		return nil
+	}
+
+	if err := s.snapshotter.Save(s.lastSeq); err != nil {
+		return err
+	}
+	s.sinceSnapshot = 0
+	if s.commandLog == nil {
+		return nil
+	}
+
+	return s.commandLog.Truncate()
+}
+
+// applyCommand manages command types and calls the appropriate method
+func (s *Service) applyCommand(ctx context.Context, cmd model.Command) error {
 	switch cmd.Type {
 	case model.CommandTypeSell:
-		err = s.processSell(ctx, cmd)
+		return s.processSell(ctx, cmd)
 	case model.CommandTypeBid:
-		err = s.processBid(ctx, cmd)
+		return s.processBid(ctx, cmd)
 	case model.CommandTypeHeartbeat:
-		err = s.processHeartbeat(ctx, cmd)
+		return s.processHeartbeat(ctx, cmd)
+	case model.CommandTypeCancel:
+		return s.processCancel(ctx, cmd)
+	case model.CommandTypeRetract:
+		return s.processRetract(ctx, cmd)
+	case model.CommandTypeReserve:
+		return s.processReserve(ctx, cmd)
+	case model.CommandTypePayment:
+		return s.processPayment(ctx, cmd)
+	case model.CommandTypeDeposit:
+		return s.processDeposit(ctx, cmd)
+	case model.CommandTypeAdmin:
+		return s.processAdmin(ctx, cmd)
 	default:
-		err = model.ErrUnknownCommandType
-	}
-
-	if err != nil {
-		s.errCh <- err
+		return model.ErrUnknownCommandType
 	}
 }
 
@@ -109,6 +247,17 @@ func (s *Service) processSell(ctx context.Context, cmd model.Command) error {
 	if cmd.Sell == nil {
 		return model.ErrInvalidData
 	}
+	if cmd.Sell.StartTime != 0 && (cmd.Sell.StartTime < cmd.Sell.Timestamp || cmd.Sell.StartTime > cmd.Sell.CloseTime) {
+		return model.ErrInvalidData
+	}
+	if cmd.Sell.StartPrice < 0 || cmd.Sell.DepositRate < 0 || cmd.Sell.DepositRate > model.BasisPointsInUnit {
+		return model.ErrInvalidData
+	}
+	if s.userService != nil {
+		if err := s.userService.CheckSeller(cmd.Sell.UserID); err != nil {
+			return err
+		}
+	}
 	order := s.newOrder(*cmd.Sell)
 	return s.storage.CreateOrder(ctx, order)
 }
@@ -118,10 +267,29 @@ func (s *Service) processBid(ctx context.Context, cmd model.Command) error {
 	if cmd.Bid == nil {
 		return model.ErrInvalidData
 	}
+	if s.userService != nil {
+		if err := s.userService.CheckBuyer(cmd.Bid.UserID); err != nil {
+			return err
+		}
+	}
 
 	return s.storage.BidOrder(ctx, *cmd.Bid)
 }
 
+// processDeposit processes the deposit posted by the bidder
+func (s *Service) processDeposit(ctx context.Context, cmd model.Command) error {
+	if cmd.Deposit == nil {
+		return model.ErrInvalidData
+	}
+	if s.userService != nil {
+		if err := s.userService.CheckBuyer(cmd.Deposit.UserID); err != nil {
+			return err
+		}
+	}
+
+	return s.storage.PostDeposit(ctx, *cmd.Deposit)
+}
+
 // processHeartbeat processes heartbeat. We check do we need to finish some orders by the time
 func (s *Service) processHeartbeat(ctx context.Context, cmd model.Command) error {
 	if cmd.Heartbeat == nil {
@@ -131,14 +299,104 @@ func (s *Service) processHeartbeat(ctx context.Context, cmd model.Command) error
 	return s.storage.FinishExpiredAuctions(ctx, cmd.Heartbeat.Timestamp)
 }
 
+// processCancel processes withdrawing an item by its seller
+func (s *Service) processCancel(ctx context.Context, cmd model.Command) error {
+	if cmd.Cancel == nil {
+		return model.ErrInvalidData
+	}
+
+	return s.storage.CancelOrder(ctx, *cmd.Cancel)
+}
+
+// processRetract processes withdrawing the latest bid of a user
+func (s *Service) processRetract(ctx context.Context, cmd model.Command) error {
+	if cmd.Retract == nil {
+		return model.ErrInvalidData
+	}
+
+	return s.storage.RetractBid(ctx, *cmd.Retract)
+}
+
+// processReserve processes lowering the reserve price by the seller
+func (s *Service) processReserve(ctx context.Context, cmd model.Command) error {
+	if cmd.Reserve == nil {
+		return model.ErrInvalidData
+	}
+
+	return s.storage.LowerReserve(ctx, *cmd.Reserve)
+}
+
+// processPayment processes the payment outcome of the buyer of the sold item
+func (s *Service) processPayment(ctx context.Context, cmd model.Command) error {
+	if cmd.Payment == nil {
+		return model.ErrInvalidData
+	}
+
+	return s.storage.RecordPayment(ctx, *cmd.Payment)
+}
+
+// processAdmin processes privileged commands for fixing auction incidents
+func (s *Service) processAdmin(ctx context.Context, cmd model.Command) error {
+	if cmd.Admin == nil {
+		return model.ErrInvalidData
+	}
+
+	if _, ok := s.admins[cmd.Admin.UserID]; !ok {
+		return model.ErrPermissionDenied
+	}
+
+	switch cmd.Admin.Action {
+	case model.AdminActionExtend:
+		return s.storage.ExtendAuction(ctx, *cmd.Admin)
+	case model.AdminActionForceClose:
+		return s.storage.ForceCloseAuction(ctx, *cmd.Admin)
+	case model.AdminActionVoid:
+		return s.storage.VoidAuction(ctx, *cmd.Admin)
+	case model.AdminActionReopen:
+		return s.storage.ReopenAuction(ctx, *cmd.Admin)
+	case model.AdminActionRefund:
+		return s.storage.RefundOrder(ctx, *cmd.Admin)
+	case model.AdminActionSettle:
+		return s.storage.SettleOrder(ctx, *cmd.Admin)
+	case model.AdminActionActivate:
+		return s.setUserStatus(cmd.Admin.TargetUserID, model.UserStatusActive)
+	case model.AdminActionSuspend:
+		return s.setUserStatus(cmd.Admin.TargetUserID, model.UserStatusSuspended)
+	case model.AdminActionBan:
+		return s.setUserStatus(cmd.Admin.TargetUserID, model.UserStatusBanned)
+	default:
+		return model.ErrUnknownCommandType
+	}
+}
+
+// setUserStatus changes the status of the user in the users registry
+func (s *Service) setUserStatus(userID int, status model.UserStatus) error {
+	if s.userService == nil {
+		return model.ErrInvalidData
+	}
+
+	return s.userService.SetStatus(userID, status)
+}
+
 // newOrder makes new order instance
 func (s *Service) newOrder(sellOrder model.SellCommand) model.Order {
+	startTime := sellOrder.StartTime
+	if startTime == 0 {
+		// auction starts immediately
+		startTime = sellOrder.Timestamp
+	}
+
 	return model.Order{
 		Item: model.Item{
 			Name:         sellOrder.ItemName,
+			StartPrice:   sellOrder.StartPrice,
 			ReservePrice: sellOrder.ReservePrice,
+			Currency:     sellOrder.Currency,
+			DepositRate:  sellOrder.DepositRate,
 		},
+		SellerID:     sellOrder.UserID,
 		CreationTime: sellOrder.Timestamp,
+		StartTime:    startTime,
 		Status:       model.OrderStatusInit,
 		CloseTime:    sellOrder.CloseTime,
 	}
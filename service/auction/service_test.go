@@ -30,11 +30,11 @@ func TestService_Start(t *testing.T) {
 			Item:         "phone_1",
 			UserID:       3,
 			Status:       "SOLD",
-			PricePaid:    12.34,
+			PricePaid:    1234,
 			Statistics: model.AuctionStatistics{
 				TotalBidCount: 3,
-				HighestBid:    13.45,
-				LowestBid:     9.31,
+				HighestBid:    1345,
+				LowestBid:     931,
 			},
 		},
 	}
@@ -59,7 +59,7 @@ func TestService_Start(t *testing.T) {
 					Timestamp:    10,
 					UserID:       1,
 					ItemName:     "phone_1",
-					ReservePrice: 10.10,
+					ReservePrice: 1010,
 					CloseTime:    20,
 				}
 				order := model.Order{
@@ -67,7 +67,9 @@ func TestService_Start(t *testing.T) {
 						Name:         sellCmd.ItemName,
 						ReservePrice: sellCmd.ReservePrice,
 					},
+					SellerID:     sellCmd.UserID,
 					CreationTime: sellCmd.Timestamp,
+					StartTime:    sellCmd.Timestamp,
 					Status:       model.OrderStatusInit,
 					CloseTime:    sellCmd.CloseTime,
 				}
@@ -92,6 +94,54 @@ func TestService_Start(t *testing.T) {
 			},
 			hasErr: false,
 		},
+		{
+			name: "success/data/sell_scheduled",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter)
+
+				sellCmds := []model.SellCommand{
+					{Timestamp: 10, UserID: 1, ItemName: "phone_1", ReservePrice: 1010, CloseTime: 20, StartTime: 15},
+					{Timestamp: 10, UserID: 1, ItemName: "phone_2", ReservePrice: 1010, CloseTime: 20, StartTime: 25}, // invalid start time
+				}
+				order := model.Order{
+					Item: model.Item{
+						Name:         sellCmds[0].ItemName,
+						ReservePrice: sellCmds[0].ReservePrice,
+					},
+					SellerID:     sellCmds[0].UserID,
+					CreationTime: sellCmds[0].Timestamp,
+					StartTime:    sellCmds[0].StartTime,
+					Status:       model.OrderStatusInit,
+					CloseTime:    sellCmds[0].CloseTime,
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().CreateOrder(ctx, order).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed sell commands
+					for idx := range sellCmds {
+						s.commandCh <- model.Command{
+							Type: model.CommandTypeSell,
+							Sell: &sellCmds[idx],
+						}
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
 		{
 			name: "success/data/bid",
 			init: func(t *testing.T, ctx context.Context, filename string) *Service {
@@ -106,7 +156,7 @@ func TestService_Start(t *testing.T) {
 					Timestamp: 11,
 					UserID:    3,
 					ItemName:  "phone_1",
-					BidAmount: 10.34,
+					BidAmount: 1034,
 				}
 
 				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
@@ -129,6 +179,318 @@ func TestService_Start(t *testing.T) {
 			},
 			hasErr: false,
 		},
+		{
+			name: "success/data/cancel",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter)
+
+				cancelCmd := model.CancelCommand{
+					Timestamp: 12,
+					UserID:    1,
+					ItemName:  "phone_1",
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().CancelOrder(ctx, cancelCmd).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed cancel command
+					s.commandCh <- model.Command{
+						Type:   model.CommandTypeCancel,
+						Cancel: &cancelCmd,
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/data/retract",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter)
+
+				retractCmd := model.RetractCommand{
+					Timestamp: 12,
+					UserID:    3,
+					ItemName:  "phone_1",
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().RetractBid(ctx, retractCmd).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed retract command
+					s.commandCh <- model.Command{
+						Type:    model.CommandTypeRetract,
+						Retract: &retractCmd,
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/data/reserve",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter)
+
+				reserveCmd := model.ReserveCommand{
+					Timestamp:    12,
+					UserID:       1,
+					ItemName:     "phone_1",
+					ReservePrice: 850,
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().LowerReserve(ctx, reserveCmd).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed reserve command
+					s.commandCh <- model.Command{
+						Type:    model.CommandTypeReserve,
+						Reserve: &reserveCmd,
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/data/deposit",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter)
+
+				depositCmd := model.DepositCommand{
+					Timestamp: 11,
+					UserID:    3,
+					ItemName:  "phone_1",
+					Amount:    200,
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().PostDeposit(ctx, depositCmd).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed deposit command
+					s.commandCh <- model.Command{
+						Type:    model.CommandTypeDeposit,
+						Deposit: &depositCmd,
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/data/payment",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter)
+
+				paymentCmd := model.PaymentCommand{
+					Timestamp: 25,
+					UserID:    3,
+					ItemName:  "phone_1",
+					Outcome:   model.OfferStatusDefaulted,
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().RecordPayment(ctx, paymentCmd).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed payment command
+					s.commandCh <- model.Command{
+						Type:    model.CommandTypePayment,
+						Payment: &paymentCmd,
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/data/admin",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter, WithAdmins(99))
+
+				adminCmds := []model.AdminCommand{
+					{Timestamp: 12, UserID: 99, Action: model.AdminActionExtend, ItemName: "phone_1", CloseTime: 30},
+					{Timestamp: 13, UserID: 99, Action: model.AdminActionForceClose, ItemName: "phone_1"},
+					{Timestamp: 14, UserID: 99, Action: model.AdminActionReopen, ItemName: "phone_1", CloseTime: 40},
+					{Timestamp: 15, UserID: 99, Action: model.AdminActionVoid, ItemName: "phone_1"},
+					{Timestamp: 16, UserID: 99, Action: model.AdminActionSettle, ItemName: "phone_2"},
+					{Timestamp: 17, UserID: 99, Action: model.AdminActionRefund, ItemName: "phone_3"},
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				gomock.InOrder(
+					storage.EXPECT().ExtendAuction(ctx, adminCmds[0]).Return(nil),
+					storage.EXPECT().ForceCloseAuction(ctx, adminCmds[1]).Return(nil),
+					storage.EXPECT().ReopenAuction(ctx, adminCmds[2]).Return(nil),
+					storage.EXPECT().VoidAuction(ctx, adminCmds[3]).Return(nil),
+					storage.EXPECT().SettleOrder(ctx, adminCmds[4]).Return(nil),
+					storage.EXPECT().RefundOrder(ctx, adminCmds[5]).Return(nil),
+				)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed admin commands
+					for idx := range adminCmds {
+						s.commandCh <- model.Command{
+							Type:  model.CommandTypeAdmin,
+							Admin: &adminCmds[idx],
+						}
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/data/admin_permission_denied",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+
+				s := New(storage, reader, reporter, WithAdmins(99))
+
+				adminCmd := model.AdminCommand{
+					Timestamp: 12,
+					UserID:    1,
+					Action:    model.AdminActionVoid,
+					ItemName:  "phone_1",
+				}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed admin command from not admin user
+					s.commandCh <- model.Command{
+						Type:  model.CommandTypeAdmin,
+						Admin: &adminCmd,
+					}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/data/user_checks",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+				users := mock.NewMockUserService(ctrl)
+
+				s := New(storage, reader, reporter, WithAdmins(99), WithUserService(users))
+
+				sellCmd := model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone_1", ReservePrice: 1010, CloseTime: 20}
+				bidCmds := []model.BidCommand{
+					{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 1034},
+					{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 1100},
+				}
+				banCmd := model.AdminCommand{Timestamp: 13, UserID: 99, Action: model.AdminActionBan, TargetUserID: 3}
+
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				gomock.InOrder(
+					users.EXPECT().CheckSeller(1).Return(model.ErrUserSuspended),
+					users.EXPECT().CheckBuyer(3).Return(nil),
+					storage.EXPECT().BidOrder(ctx, bidCmds[0]).Return(nil),
+					users.EXPECT().CheckBuyer(4).Return(model.ErrUserBanned),
+					users.EXPECT().SetStatus(3, model.UserStatusBanned).Return(nil),
+				)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate sending parsed commands, rejected ones don't reach the storage
+					s.commandCh <- model.Command{Type: model.CommandTypeSell, Sell: &sellCmd}
+					for idx := range bidCmds {
+						s.commandCh <- model.Command{Type: model.CommandTypeBid, Bid: &bidCmds[idx]}
+					}
+					s.commandCh <- model.Command{Type: model.CommandTypeAdmin, Admin: &banCmd}
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
 		{
 			name: "success/data/heartbeat",
 			init: func(t *testing.T, ctx context.Context, filename string) *Service {
@@ -163,6 +525,97 @@ func TestService_Start(t *testing.T) {
 			},
 			hasErr: false,
 		},
+		{
+			name: "success/command_log/recover",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+				commandLog := mock.NewMockCommandLog(ctrl)
+				snapshotter := mock.NewMockSnapshotter(ctrl)
+
+				s := New(storage, reader, reporter, WithCommandLog(commandLog), WithSnapshots(snapshotter, 1))
+
+				loggedCmd := model.Command{
+					Type: model.CommandTypeBid,
+					Seq:  3,
+					Bid:  &model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 1034},
+				}
+				newCmd := model.Command{
+					Type: model.CommandTypeBid,
+					Seq:  4,
+					Bid:  &model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 1100},
+				}
+
+				// the snapshot is taken after the second command, the third one is replayed from the log
+				snapshotter.EXPECT().Load().Return(int64(2), nil)
+				commandLog.EXPECT().Replay(int64(2), gomock.Any()).DoAndReturn(
+					func(_ int64, fn func(cmd model.Command) error) error {
+						return fn(loggedCmd)
+					})
+				storage.EXPECT().BidOrder(ctx, *loggedCmd.Bid).Return(testErr)
+
+				// applied commands are skipped, the new one is logged before it is applied
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				gomock.InOrder(
+					commandLog.EXPECT().Append(newCmd).Return(nil),
+					storage.EXPECT().BidOrder(ctx, *newCmd.Bid).Return(nil),
+					snapshotter.EXPECT().Save(int64(4)).Return(nil),
+					commandLog.EXPECT().Truncate().Return(nil),
+				)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					// imitate reading the file again after the restart
+					s.commandCh <- model.Command{Type: model.CommandTypeSell, Seq: 1}
+					s.commandCh <- model.Command{Type: model.CommandTypeBid, Seq: 3}
+					s.commandCh <- newCmd
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
+		{
+			name: "success/command_log/append_error",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+				commandLog := mock.NewMockCommandLog(ctrl)
+
+				s := New(storage, reader, reporter, WithCommandLog(commandLog))
+
+				bidCmd := model.Command{
+					Type: model.CommandTypeBid,
+					Seq:  1,
+					Bid:  &model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 1034},
+				}
+
+				// the command not written to the log is not applied
+				commandLog.EXPECT().Replay(int64(0), gomock.Any()).Return(nil)
+				commandLog.EXPECT().Append(bidCmd).Return(testErr)
+				reader.EXPECT().Read(filename, s.commandCh).Return(nil)
+				storage.EXPECT().FinishAllAuctions(ctx).Return(nil)
+				storage.EXPECT().GetAuctionResults(ctx).Return(ar, nil)
+				reporter.EXPECT().Report(ar).Return(nil)
+
+				go func() {
+					s.commandCh <- bidCmd
+					time.Sleep(time.Second * 1)
+					close(s.commandCh) // imitate finishing of file reading
+				}()
+
+				return s
+			},
+			hasErr: false,
+		},
 		{
 			name: "success/no_data",
 			init: func(t *testing.T, ctx context.Context, filename string) *Service {
@@ -254,6 +707,37 @@ func TestService_Start(t *testing.T) {
 			},
 			hasErr: true,
 		},
+		{
+			name: "err/snapshot",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+				commandLog := mock.NewMockCommandLog(ctrl)
+				snapshotter := mock.NewMockSnapshotter(ctrl)
+
+				snapshotter.EXPECT().Load().Return(int64(0), testErr)
+
+				return New(storage, reader, reporter, WithCommandLog(commandLog), WithSnapshots(snapshotter, 1))
+			},
+			hasErr: true,
+		},
+		{
+			name: "err/command_log",
+			init: func(t *testing.T, ctx context.Context, filename string) *Service {
+				ctrl := gomock.NewController(t)
+				storage := mock.NewMockStorage(ctrl)
+				reader := mock.NewMockReadService(ctrl)
+				reporter := mock.NewMockReportService(ctrl)
+				commandLog := mock.NewMockCommandLog(ctrl)
+
+				commandLog.EXPECT().Replay(int64(0), gomock.Any()).Return(testErr)
+
+				return New(storage, reader, reporter, WithCommandLog(commandLog))
+			},
+			hasErr: true,
+		},
 		{
 			name: "err/reader",
 			init: func(t *testing.T, ctx context.Context, filename string) *Service {
@@ -0,0 +1,148 @@
+package currency
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// rateScale is the number of fractional units in the exchange rate, rates are kept with 6 decimal places
+const rateScale = 1000000
+
+// rate is the exchange rate of the currency to the reporting one, in effect since the timestamp
+type rate struct {
+	timestamp int64
+	value     int64 // reporting currency units per one currency unit multiplied by rateScale
+}
+
+// Service converts money between currencies by the exchange rate table loaded from the local file.
+// All rates are set against the site's reporting currency.
+type Service struct {
+	reportingCurrency model.Currency
+	rates             map[model.Currency][]rate // key - currency, value - rates sorted by time
+}
+
+func New(reportingCurrency model.Currency) *Service {
+	return &Service{
+		reportingCurrency: reportingCurrency,
+		rates:             make(map[model.Currency][]rate),
+	}
+}
+
+// ReportingCurrency returns the site's reporting currency
+func (s *Service) ReportingCurrency() model.Currency {
+	return s.reportingCurrency
+}
+
+// Load reads the exchange rate table from the file.
+// Every line has format `timestamp|currency|rate`, where rate is the price of one currency unit in the reporting currency.
+// The rate is in effect from its timestamp until the next rate of the same currency.
+func (s *Service) Load(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileScanner := bufio.NewScanner(file)
+	fileScanner.Split(bufio.ScanLines)
+
+	lineNum := 0
+	for fileScanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(fileScanner.Text())
+		if line == "" {
+			continue
+		}
+
+		currency, r, err := parseRate(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		s.rates[currency] = append(s.rates[currency], r)
+	}
+	if err = fileScanner.Err(); err != nil {
+		return err
+	}
+
+	for currency := range s.rates {
+		rates := s.rates[currency]
+		sort.SliceStable(rates, func(i, j int) bool {
+			return rates[i].timestamp < rates[j].timestamp
+		})
+	}
+
+	return nil
+}
+
+// Convert converts the amount between currencies by the rates in effect at the given time.
+// Empty currency means the reporting currency.
+func (s *Service) Convert(amount model.Money, from, to model.Currency, timestamp int64) (model.Money, error) {
+	fromRate, err := s.getRate(from, timestamp)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := s.getRate(to, timestamp)
+	if err != nil {
+		return 0, err
+	}
+
+	if fromRate == toRate {
+		return amount, nil
+	}
+
+	return amount.MulDiv(fromRate, toRate), nil
+}
+
+// getRate returns the rate of the currency to the reporting one in effect at the given time
+func (s *Service) getRate(currency model.Currency, timestamp int64) (int64, error) {
+	if currency == "" || currency == s.reportingCurrency {
+		return rateScale, nil
+	}
+
+	rates := s.rates[currency]
+	// find the first rate that is not in effect yet
+	idx := sort.Search(len(rates), func(i int) bool {
+		return rates[i].timestamp > timestamp
+	})
+	if idx == 0 {
+		return 0, fmt.Errorf("%w: no %s rate at %d", model.ErrUnsupportedCurrency, currency, timestamp)
+	}
+
+	return rates[idx-1].value, nil
+}
+
+// parseRate parses one line of the exchange rate table
+func parseRate(line string) (model.Currency, rate, error) {
+	elements := strings.Split(line, "|")
+	if len(elements) != 3 {
+		return "", rate{}, model.ErrInvalidData
+	}
+
+	timestamp, err := strconv.ParseInt(elements[0], 10, 64)
+	if err != nil {
+		return "", rate{}, err
+	}
+	currency, err := model.ParseCurrency(elements[1])
+	if err != nil {
+		return "", rate{}, err
+	}
+
+	// parse the rate exactly as a decimal fraction
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(elements[2]))
+	if !ok || value.Sign() <= 0 {
+		return "", rate{}, fmt.Errorf("%w: rate %q", model.ErrInvalidData, elements[2])
+	}
+	value.Mul(value, new(big.Rat).SetInt64(rateScale))
+	if !value.IsInt() || !value.Num().IsInt64() {
+		return "", rate{}, fmt.Errorf("%w: rate %q has more than 6 decimal places", model.ErrInvalidData, elements[2])
+	}
+
+	return currency, rate{timestamp: timestamp, value: value.Num().Int64()}, nil
+}
@@ -0,0 +1,111 @@
+package currency
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+func TestNew(t *testing.T) {
+	s := New("USD")
+	assert.NotNil(t, s)
+	assert.Equal(t, model.Currency("USD"), s.ReportingCurrency())
+}
+
+func TestService_Load(t *testing.T) {
+	testCases := []struct {
+		name   string
+		data   string
+		hasErr bool
+	}{
+		{
+			name: "success",
+			data: "0|EUR|1.08\n\n0|GBP|1.25\n20|EUR|1.1\n",
+		},
+		{
+			name:   "err/format",
+			data:   "0|EUR\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/currency",
+			data:   "0|EURO|1.08\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/rate",
+			data:   "0|EUR|-1.08\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/rate_precision",
+			data:   "0|EUR|1.0000001\n",
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := writeFile(t, tc.data)
+
+			err := New("USD").Load(filename)
+
+			if tc.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.Error(t, New("USD").Load(filepath.Join(t.TempDir(), "missing.txt")))
+}
+
+func TestService_Convert(t *testing.T) {
+	s := New("USD")
+	// rates are not sorted in the file
+	assert.NoError(t, s.Load(writeFile(t, "20|EUR|1.1\n10|EUR|1.08\n10|GBP|1.25\n")))
+
+	testCases := []struct {
+		name      string
+		amount    model.Money
+		from      model.Currency
+		to        model.Currency
+		timestamp int64
+		exp       model.Money
+		hasErr    bool
+	}{
+		{name: "success/same_currency", amount: 1000, from: "EUR", to: "EUR", timestamp: 15, exp: 1000},
+		{name: "success/to_reporting", amount: 1000, from: "EUR", to: "USD", timestamp: 15, exp: 1080},
+		{name: "success/to_empty_reporting", amount: 1000, from: "EUR", to: "", timestamp: 15, exp: 1080},
+		{name: "success/from_reporting", amount: 1080, from: "", to: "EUR", timestamp: 15, exp: 1000},
+		{name: "success/new_rate", amount: 1000, from: "EUR", to: "USD", timestamp: 20, exp: 1100},
+		{name: "success/cross", amount: 1000, from: "GBP", to: "EUR", timestamp: 25, exp: 1136},
+		{name: "err/no_rate_yet", amount: 1000, from: "EUR", to: "USD", timestamp: 5, hasErr: true},
+		{name: "err/unknown", amount: 1000, from: "JPY", to: "USD", timestamp: 15, hasErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := s.Convert(tc.amount, tc.from, tc.to, tc.timestamp)
+
+			if tc.hasErr {
+				assert.ErrorIs(t, err, model.ErrUnsupportedCurrency)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.exp, res)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, data string) string {
+	filename := filepath.Join(t.TempDir(), "rates.txt")
+	assert.NoError(t, os.WriteFile(filename, []byte(data), 0o600))
+
+	return filename
+}
@@ -0,0 +1,89 @@
+package fee
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// Tier is the seller commission rate applied to the part of the hammer price above the tier start
+type Tier struct {
+	From model.Money
+	Rate int64 // in basis points, 1% = 100
+}
+
+// Service computes fees the auction house earns on the hammer price:
+// buyer's premium paid by the winner on top of the price, and tiered seller commission.
+type Service struct {
+	buyerPremiumRate int64 // in basis points
+	commissionTiers  []Tier
+}
+
+func New(buyerPremiumRate int64, commissionTiers []Tier) *Service {
+	tiers := append([]Tier(nil), commissionTiers...)
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[i].From < tiers[j].From
+	})
+
+	return &Service{
+		buyerPremiumRate: buyerPremiumRate,
+		commissionTiers:  tiers,
+	}
+}
+
+// BuyerPremium returns the buyer's premium on the hammer price
+func (s *Service) BuyerPremium(hammerPrice model.Money) model.Money {
+	return hammerPrice.MulDiv(s.buyerPremiumRate, model.BasisPointsInUnit)
+}
+
+// SellerCommission returns the seller commission on the hammer price.
+// Every tier rate is applied only to the part of the price within the tier, like tax brackets.
+func (s *Service) SellerCommission(hammerPrice model.Money) model.Money {
+	var weighted model.Money // sum of the price parts multiplied by their rates
+	for idx, tier := range s.commissionTiers {
+		if hammerPrice <= tier.From {
+			break
+		}
+
+		upper := hammerPrice
+		if idx+1 < len(s.commissionTiers) && s.commissionTiers[idx+1].From < hammerPrice {
+			upper = s.commissionTiers[idx+1].From
+		}
+		weighted += (upper - tier.From) * model.Money(tier.Rate)
+	}
+
+	// round once for the whole commission
+	return weighted.MulDiv(1, model.BasisPointsInUnit)
+}
+
+// ParseTiers parses comma separated commission tiers like "0:10,1000:7.5,10000:5",
+// where every tier is the price the tier starts from and its percent
+func ParseTiers(str string) ([]Tier, error) {
+	if strings.TrimSpace(str) == "" {
+		return nil, nil
+	}
+
+	elements := strings.Split(str, ",")
+	tiers := make([]Tier, 0, len(elements))
+	for _, el := range elements {
+		from, percent, ok := strings.Cut(el, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: tier %q", model.ErrInvalidData, el)
+		}
+
+		fromPrice, err := model.ParseMoney(from)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := model.ParsePercent(percent)
+		if err != nil {
+			return nil, err
+		}
+
+		tiers = append(tiers, Tier{From: fromPrice, Rate: rate})
+	}
+
+	return tiers, nil
+}
@@ -0,0 +1,75 @@
+package fee
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+func TestNew(t *testing.T) {
+	assert.NotNil(t, New(0, nil))
+}
+
+func TestService_BuyerPremium(t *testing.T) {
+	s := New(1250, nil) // 12.5%
+
+	assert.Equal(t, model.Money(0), s.BuyerPremium(0))
+	assert.Equal(t, model.Money(1250), s.BuyerPremium(10000))
+	assert.Equal(t, model.Money(156), s.BuyerPremium(1250)) // 1.5625 rounded
+	assert.Equal(t, model.Money(0), New(0, nil).BuyerPremium(10000))
+}
+
+func TestService_SellerCommission(t *testing.T) {
+	// 10% up to 1000.00, 7.5% up to 10000.00, 5% above. Tiers are not sorted
+	s := New(0, []Tier{
+		{From: 1000000, Rate: 500},
+		{From: 0, Rate: 1000},
+		{From: 100000, Rate: 750},
+	})
+
+	testCases := []struct {
+		name        string
+		hammerPrice model.Money
+		exp         model.Money
+	}{
+		{name: "zero", hammerPrice: 0, exp: 0},
+		{name: "first_tier", hammerPrice: 50000, exp: 5000},
+		{name: "tier_border", hammerPrice: 100000, exp: 10000},
+		{name: "second_tier", hammerPrice: 200000, exp: 17500},
+		{name: "third_tier", hammerPrice: 2000000, exp: 127500},
+		{name: "rounding", hammerPrice: 100001, exp: 10000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.exp, s.SellerCommission(tc.hammerPrice))
+		})
+	}
+
+	assert.Equal(t, model.Money(0), New(0, nil).SellerCommission(10000))
+}
+
+func TestParseTiers(t *testing.T) {
+	tiers, err := ParseTiers("0:10, 1000:7.5,10000.00:5")
+	assert.NoError(t, err)
+	assert.Equal(t, []Tier{
+		{From: 0, Rate: 1000},
+		{From: 100000, Rate: 750},
+		{From: 1000000, Rate: 500},
+	}, tiers)
+
+	tiers, err = ParseTiers("")
+	assert.NoError(t, err)
+	assert.Empty(t, tiers)
+
+	_, err = ParseTiers("0-10")
+	assert.Error(t, err)
+	_, err = ParseTiers("a:10")
+	assert.Error(t, err)
+	_, err = ParseTiers("0:-1")
+	assert.Error(t, err)
+	_, err = ParseTiers("0:1.001")
+	assert.Error(t, err)
+}
@@ -0,0 +1,264 @@
+package ledger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// Account is the ledger account. Amounts are debited with the positive sign and credited with the negative one
+type Account string
+
+// list of the auction house accounts
+const (
+	AccountEscrow     Account = "escrow"      // money of buyers held until the settlement
+	AccountFeeRevenue Account = "fee_revenue" // buyer's premiums and seller commissions earned
+	AccountHouseCash  Account = "house_cash"  // fees paid out from the escrow to the auction house
+)
+
+// BuyerReceivable is the account of the money the buyer owes for the sold items
+func BuyerReceivable(userID int) Account {
+	return Account(fmt.Sprintf("buyer_receivable:%d", userID))
+}
+
+// SellerPayable is the account of the money owed to the seller for the sold items
+func SellerPayable(userID int) Account {
+	return Account(fmt.Sprintf("seller_payable:%d", userID))
+}
+
+// Posting is the amount debited or credited to the account
+type Posting struct {
+	Account Account
+	Amount  model.Money
+}
+
+// Entry is the balanced set of postings made by the ledger event
+type Entry struct {
+	Timestamp int64
+	Item      string
+	Currency  model.Currency
+	Event     model.LedgerEventType
+	Postings  []Posting
+}
+
+type balanceKey struct {
+	account  Account
+	currency model.Currency
+}
+
+// Service is the double-entry ledger of money movements of sold auctions.
+type Service struct {
+	mx sync.Mutex
+
+	entries      []Entry
+	balances     map[balanceKey]model.Money
+	itemBalances map[string]map[Account]model.Money // key - item name
+}
+
+func New() *Service {
+	return &Service{
+		balances:     make(map[balanceKey]model.Money),
+		itemBalances: make(map[string]map[Account]model.Money),
+	}
+}
+
+// Record posts the entry of the ledger event
+func (s *Service) Record(event model.LedgerEvent) error {
+	postings, err := getPostings(event)
+	if err != nil {
+		return err
+	}
+
+	return s.Post(Entry{
+		Timestamp: event.Timestamp,
+		Item:      event.Item,
+		Currency:  event.Currency,
+		Event:     event.Type,
+		Postings:  postings,
+	})
+}
+
+// Post checks the entry is balanced and applies it to account balances
+func (s *Service) Post(entry Entry) error {
+	var sum model.Money
+	for _, posting := range entry.Postings {
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return model.ErrUnbalancedEntry
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	itemBalances, ok := s.itemBalances[entry.Item]
+	if !ok {
+		itemBalances = make(map[Account]model.Money)
+		s.itemBalances[entry.Item] = itemBalances
+	}
+	for _, posting := range entry.Postings {
+		s.balances[balanceKey{account: posting.Account, currency: entry.Currency}] += posting.Amount
+		itemBalances[posting.Account] += posting.Amount
+	}
+	s.entries = append(s.entries, entry)
+
+	return nil
+}
+
+// Entries returns posted entries in the order they were posted
+func (s *Service) Entries() []Entry {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return append([]Entry(nil), s.entries...)
+}
+
+// Restore replaces the ledger with the posted entries
+func (s *Service) Restore(entries []Entry) error {
+	s.mx.Lock()
+	s.entries = nil
+	s.balances = make(map[balanceKey]model.Money)
+	s.itemBalances = make(map[string]map[Account]model.Money)
+	s.mx.Unlock()
+
+	for _, entry := range entries {
+		if err := s.Post(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TrialBalance returns not zero balances of accounts sorted by currency and account
+func (s *Service) TrialBalance() []model.LedgerBalance {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	res := make([]model.LedgerBalance, 0, len(s.balances))
+	for key, amount := range s.balances {
+		balance := model.LedgerBalance{Account: string(key.account), Currency: key.currency}
+		switch {
+		case amount > 0:
+			balance.Debit = amount
+		case amount < 0:
+			balance.Credit = -amount
+		default:
+			continue
+		}
+		res = append(res, balance)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Currency != res[j].Currency {
+			return res[i].Currency < res[j].Currency
+		}
+		return res[i].Account < res[j].Account
+	})
+
+	return res
+}
+
+// Reconcile checks balances of the item accounts match the auction result
+func (s *Service) Reconcile(result model.ActionResult) error {
+	expected := getExpectedBalances(result)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	actual := s.itemBalances[result.Item]
+	for account, amount := range actual {
+		if amount != expected[account] {
+			return fmt.Errorf("%w: item %s, account %s", model.ErrLedgerMismatch, result.Item, account)
+		}
+	}
+	for account, amount := range expected {
+		if amount != actual[account] {
+			return fmt.Errorf("%w: item %s, account %s", model.ErrLedgerMismatch, result.Item, account)
+		}
+	}
+
+	return nil
+}
+
+// getPostings returns postings of the ledger event
+func getPostings(event model.LedgerEvent) ([]Posting, error) {
+	var (
+		buyerTotal   = event.Price + event.BuyerPremium
+		sellerPayout = event.Price - event.SellerCommission
+		fees         = event.BuyerPremium + event.SellerCommission
+	)
+
+	switch event.Type {
+	case model.LedgerEventSale:
+		return []Posting{
+			{Account: BuyerReceivable(event.BuyerID), Amount: buyerTotal},
+			{Account: SellerPayable(event.SellerID), Amount: -sellerPayout},
+			{Account: AccountFeeRevenue, Amount: -fees},
+		}, nil
+	case model.LedgerEventSaleReversal:
+		return []Posting{
+			{Account: BuyerReceivable(event.BuyerID), Amount: -buyerTotal},
+			{Account: SellerPayable(event.SellerID), Amount: sellerPayout},
+			{Account: AccountFeeRevenue, Amount: fees},
+		}, nil
+	case model.LedgerEventPayment:
+		return []Posting{
+			{Account: AccountEscrow, Amount: buyerTotal},
+			{Account: BuyerReceivable(event.BuyerID), Amount: -buyerTotal},
+		}, nil
+	case model.LedgerEventSettlement:
+		return []Posting{
+			{Account: SellerPayable(event.SellerID), Amount: sellerPayout},
+			{Account: AccountHouseCash, Amount: fees},
+			{Account: AccountEscrow, Amount: -buyerTotal},
+		}, nil
+	case model.LedgerEventRefund:
+		return []Posting{
+			{Account: SellerPayable(event.SellerID), Amount: sellerPayout},
+			{Account: AccountFeeRevenue, Amount: fees},
+			{Account: AccountEscrow, Amount: -buyerTotal},
+		}, nil
+	default:
+		return nil, model.ErrInvalidData
+	}
+}
+
+// getExpectedBalances returns not zero balances of the item accounts by the settlement status of the auction result
+func getExpectedBalances(result model.ActionResult) map[Account]model.Money {
+	res := make(map[Account]model.Money)
+	if result.Status != model.OrderStatusSold {
+		return res
+	}
+
+	var (
+		buyerTotal   = result.PricePaid + result.BuyerPremium
+		sellerPayout = result.PricePaid - result.SellerCommission
+		fees         = result.BuyerPremium + result.SellerCommission
+	)
+
+	switch result.SettlementStatus {
+	case model.SettlementStatusAwaitingPayment:
+		res[BuyerReceivable(result.UserID)] = buyerTotal
+		res[SellerPayable(result.SellerID)] = -sellerPayout
+		res[AccountFeeRevenue] = -fees
+	case model.SettlementStatusPaid:
+		res[AccountEscrow] = buyerTotal
+		res[SellerPayable(result.SellerID)] = -sellerPayout
+		res[AccountFeeRevenue] = -fees
+	case model.SettlementStatusSettled:
+		res[AccountHouseCash] = fees
+		res[AccountFeeRevenue] = -fees
+	}
+
+	// zero balances are not compared
+	for account, amount := range res {
+		if amount == 0 {
+			delete(res, account)
+		}
+	}
+
+	return res
+}
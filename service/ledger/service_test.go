@@ -0,0 +1,202 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+func TestNew(t *testing.T) {
+	s := New()
+	assert.NotNil(t, s)
+	assert.Empty(t, s.TrialBalance())
+}
+
+func TestService_Record(t *testing.T) {
+	sale := model.LedgerEvent{
+		Item:             "phone_1",
+		SellerID:         1,
+		BuyerID:          3,
+		Price:            10000,
+		BuyerPremium:     1000,
+		SellerCommission: 500,
+	}
+
+	testCases := []struct {
+		name        string
+		events      []model.LedgerEventType
+		expBalances map[Account]model.Money
+		hasErr      bool
+	}{
+		{
+			name:   "success/sale",
+			events: []model.LedgerEventType{model.LedgerEventSale},
+			expBalances: map[Account]model.Money{
+				BuyerReceivable(3): 11000,
+				SellerPayable(1):   -9500,
+				AccountFeeRevenue:  -1500,
+			},
+		},
+		{
+			name:   "success/payment",
+			events: []model.LedgerEventType{model.LedgerEventSale, model.LedgerEventPayment},
+			expBalances: map[Account]model.Money{
+				BuyerReceivable(3): 0,
+				AccountEscrow:      11000,
+				SellerPayable(1):   -9500,
+				AccountFeeRevenue:  -1500,
+			},
+		},
+		{
+			name:   "success/settlement",
+			events: []model.LedgerEventType{model.LedgerEventSale, model.LedgerEventPayment, model.LedgerEventSettlement},
+			expBalances: map[Account]model.Money{
+				BuyerReceivable(3): 0,
+				AccountEscrow:      0,
+				SellerPayable(1):   0,
+				AccountHouseCash:   1500,
+				AccountFeeRevenue:  -1500,
+			},
+		},
+		{
+			name:   "success/refund",
+			events: []model.LedgerEventType{model.LedgerEventSale, model.LedgerEventPayment, model.LedgerEventRefund},
+			expBalances: map[Account]model.Money{
+				BuyerReceivable(3): 0,
+				AccountEscrow:      0,
+				SellerPayable(1):   0,
+				AccountFeeRevenue:  0,
+			},
+		},
+		{
+			name:   "success/sale_reversal",
+			events: []model.LedgerEventType{model.LedgerEventSale, model.LedgerEventSaleReversal},
+			expBalances: map[Account]model.Money{
+				BuyerReceivable(3): 0,
+				SellerPayable(1):   0,
+				AccountFeeRevenue:  0,
+			},
+		},
+		{
+			name:   "err/event_type",
+			events: []model.LedgerEventType{"UNKNOWN"},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New()
+
+			var err error
+			for _, eventType := range tc.events {
+				event := sale
+				event.Type = eventType
+				if err = s.Record(event); err != nil {
+					break
+				}
+			}
+
+			if tc.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, s.Entries(), len(tc.events))
+			assert.Equal(t, tc.expBalances, s.itemBalances[sale.Item])
+		})
+	}
+}
+
+func TestService_Post(t *testing.T) {
+	s := New()
+
+	err := s.Post(Entry{
+		Item:     "phone_1",
+		Postings: []Posting{{Account: AccountEscrow, Amount: 100}, {Account: AccountHouseCash, Amount: -99}},
+	})
+	assert.ErrorIs(t, err, model.ErrUnbalancedEntry)
+	assert.Empty(t, s.Entries())
+	assert.Empty(t, s.TrialBalance())
+}
+
+func TestService_TrialBalance(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.Record(model.LedgerEvent{Type: model.LedgerEventSale, Item: "phone_1", SellerID: 1, BuyerID: 3, Price: 1000}))
+	assert.NoError(t, s.Record(model.LedgerEvent{Type: model.LedgerEventSale, Item: "phone_2", SellerID: 1, BuyerID: 3, Price: 2000, SellerCommission: 200}))
+	assert.NoError(t, s.Record(model.LedgerEvent{Type: model.LedgerEventSale, Item: "phone_3", Currency: "EUR", SellerID: 2, BuyerID: 4, Price: 500}))
+
+	assert.Equal(t, []model.LedgerBalance{
+		{Account: "buyer_receivable:3", Debit: 3000},
+		{Account: "fee_revenue", Credit: 200},
+		{Account: "seller_payable:1", Credit: 2800},
+		{Account: "buyer_receivable:4", Currency: "EUR", Debit: 500},
+		{Account: "seller_payable:2", Currency: "EUR", Credit: 500},
+	}, s.TrialBalance())
+}
+
+func TestService_Reconcile(t *testing.T) {
+	result := model.ActionResult{
+		Item:             "phone_1",
+		SellerID:         1,
+		UserID:           3,
+		Status:           model.OrderStatusSold,
+		SettlementStatus: model.SettlementStatusAwaitingPayment,
+		PricePaid:        10000,
+		BuyerPremium:     1000,
+		SellerCommission: 500,
+	}
+	event := model.LedgerEvent{
+		Type:             model.LedgerEventSale,
+		Item:             "phone_1",
+		SellerID:         1,
+		BuyerID:          3,
+		Price:            10000,
+		BuyerPremium:     1000,
+		SellerCommission: 500,
+	}
+
+	s := New()
+	// the sale is not posted yet
+	assert.ErrorIs(t, s.Reconcile(result), model.ErrLedgerMismatch)
+
+	assert.NoError(t, s.Record(event))
+	assert.NoError(t, s.Reconcile(result))
+
+	// the payment is not posted yet
+	result.SettlementStatus = model.SettlementStatusPaid
+	assert.ErrorIs(t, s.Reconcile(result), model.ErrLedgerMismatch)
+
+	event.Type = model.LedgerEventPayment
+	assert.NoError(t, s.Record(event))
+	assert.NoError(t, s.Reconcile(result))
+
+	event.Type = model.LedgerEventRefund
+	assert.NoError(t, s.Record(event))
+	result.SettlementStatus = model.SettlementStatusRefunded
+	assert.NoError(t, s.Reconcile(result))
+
+	// not sold items have no balances
+	assert.NoError(t, s.Reconcile(model.ActionResult{Item: "phone_2", Status: model.OrderStatusUnsold}))
+}
+
+func TestService_Restore(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.Record(model.LedgerEvent{Type: model.LedgerEventSale, Item: "phone_1", SellerID: 1, BuyerID: 3, Price: 1000}))
+	assert.NoError(t, s.Record(model.LedgerEvent{Type: model.LedgerEventPayment, Item: "phone_1", SellerID: 1, BuyerID: 3, Price: 1000}))
+
+	restored := New()
+	assert.NoError(t, restored.Record(model.LedgerEvent{Type: model.LedgerEventSale, Item: "phone_2", SellerID: 2, BuyerID: 4, Price: 500}))
+	assert.NoError(t, restored.Restore(s.Entries()))
+	assert.Equal(t, s.Entries(), restored.Entries())
+	assert.Equal(t, s.TrialBalance(), restored.TrialBalance())
+
+	// unbalanced entries are rejected
+	err := restored.Restore([]Entry{{
+		Item:     "phone_1",
+		Postings: []Posting{{Account: AccountEscrow, Amount: 100}},
+	}})
+	assert.ErrorIs(t, err, model.ErrUnbalancedEntry)
+}
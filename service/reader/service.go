@@ -30,33 +30,85 @@ func (s *Service) Read(filename string, outputCh chan model.Command) error {
 	fileScanner := bufio.NewScanner(file)
 	fileScanner.Split(bufio.ScanLines)
 
+	var seq int64
 	for fileScanner.Scan() {
+		seq++
 		line := fileScanner.Text()
 		cmd := s.parseLineToCommand(line)
+		cmd.Seq = seq
 		outputCh <- cmd
 	}
 
 	return nil
 }
 
+// list of supported actions
+const (
+	actionSell    = "SELL"
+	actionBid     = "BID"
+	actionCancel  = "CANCEL"
+	actionRetract = "RETRACT"
+	actionReserve = "RESERVE"
+	actionPayment = "PAYMENT"
+	actionDeposit = "DEPOSIT"
+)
+
 // parseLineToCommand parses and determines what kind of command do we have
 func (s *Service) parseLineToCommand(line string) model.Command {
 	elements := strings.Split(line, "|")
-	switch len(elements) {
-	case 6: // sell command
+	if len(elements) == 1 { // heartbeat command
+		return model.Command{
+			Type:      model.CommandTypeHeartbeat,
+			Heartbeat: toHeartbeatCommand(elements),
+		}
+	}
+
+	if len(elements) < 3 {
+		return model.Command{
+			Type: model.CommandTypeUnknown,
+		}
+	}
+
+	switch {
+	case elements[2] == actionSell && len(elements) >= 6: // sell command
 		return model.Command{
 			Type: model.CommandTypeSell,
 			Sell: toSellCommand(elements),
 		}
-	case 5: // bid command
+	case elements[2] == actionBid && (len(elements) == 5 || len(elements) == 6): // bid command
 		return model.Command{
 			Type: model.CommandTypeBid,
 			Bid:  toBidCommand(elements),
 		}
-	case 1: // heartbeat command
+	case elements[2] == actionCancel && len(elements) == 4: // cancel command
 		return model.Command{
-			Type:      model.CommandTypeHeartbeat,
-			Heartbeat: toHeartbeatCommand(elements),
+			Type:   model.CommandTypeCancel,
+			Cancel: toCancelCommand(elements),
+		}
+	case elements[2] == actionRetract && len(elements) == 4: // retract command
+		return model.Command{
+			Type:    model.CommandTypeRetract,
+			Retract: toRetractCommand(elements),
+		}
+	case elements[2] == actionReserve && len(elements) == 5: // reserve command
+		return model.Command{
+			Type:    model.CommandTypeReserve,
+			Reserve: toReserveCommand(elements),
+		}
+	case elements[2] == actionDeposit && len(elements) == 5: // deposit command
+		return model.Command{
+			Type:    model.CommandTypeDeposit,
+			Deposit: toDepositCommand(elements),
+		}
+	case elements[2] == actionPayment && len(elements) == 5: // payment command
+		return model.Command{
+			Type:    model.CommandTypePayment,
+			Payment: toPaymentCommand(elements),
+		}
+	case isAdminAction(elements[2]) && len(elements) == adminCommandLen(model.AdminAction(elements[2])): // admin command
+		return model.Command{
+			Type:  model.CommandTypeAdmin,
+			Admin: toAdminCommand(elements),
 		}
 	default:
 		return model.Command{
@@ -71,7 +123,7 @@ func toSellCommand(elements []string) *model.SellCommand {
 		timestamp    int64
 		userID       int64
 		itemName     string
-		reservePrice float64
+		reservePrice model.Money
 		closeTime    int64
 	)
 	timestamp, err := strconv.ParseInt(elements[0], 10, 64)
@@ -83,7 +135,7 @@ func toSellCommand(elements []string) *model.SellCommand {
 		return nil
 	}
 	itemName = elements[3]
-	reservePrice, err = strconv.ParseFloat(elements[4], 32)
+	reservePrice, err = model.ParseMoney(elements[4])
 	if err != nil {
 		return nil
 	}
@@ -92,22 +144,61 @@ func toSellCommand(elements []string) *model.SellCommand {
 		return nil
 	}
 
-	return &model.SellCommand{
+	cmd := &model.SellCommand{
 		Timestamp:    timestamp,
 		UserID:       int(userID),
 		ItemName:     itemName,
-		ReservePrice: float32(reservePrice),
+		ReservePrice: reservePrice,
 		CloseTime:    closeTime,
 	}
+	// optional fields follow the close time as key=value options in any order
+	seen := make(map[string]bool, len(elements)-6)
+	for _, option := range elements[6:] {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok || seen[key] || !setSellOption(cmd, key, value) {
+			return nil
+		}
+		seen[key] = true
+	}
+
+	return cmd
+}
+
+// list of supported options of the sell command
+const (
+	sellOptionStartTime     = "start_time"
+	sellOptionStartingPrice = "starting_price"
+	sellOptionCurrency      = "currency"
+	sellOptionDeposit       = "deposit_percent"
+)
+
+// setSellOption sets the optional field of the sell command, returns false for unknown keys and invalid values
+func setSellOption(cmd *model.SellCommand, key, value string) bool {
+	var err error
+	switch key {
+	case sellOptionStartTime: // start time of the scheduled auction
+		cmd.StartTime, err = strconv.ParseInt(value, 10, 64)
+	case sellOptionStartingPrice: // published starting price
+		cmd.StartPrice, err = model.ParseMoney(value)
+	case sellOptionCurrency: // currency of the listing
+		cmd.Currency, err = model.ParseCurrency(value)
+	case sellOptionDeposit: // deposit percent of the reserve price required from bidders
+		cmd.DepositRate, err = model.ParsePercent(value)
+	default:
+		return false
+	}
+
+	return err == nil
 }
 
 func toBidCommand(elements []string) *model.BidCommand {
-	// skipping element index 2 - action. Always SELL
+	// skipping element index 2 - action. Always BID
 	var (
 		timestamp int64
 		userID    int64
 		itemName  string
-		bidAmount float64
+		bidAmount model.Money
+		currency  model.Currency
 	)
 	timestamp, err := strconv.ParseInt(elements[0], 10, 64)
 	if err != nil {
@@ -118,17 +209,182 @@ func toBidCommand(elements []string) *model.BidCommand {
 		return nil
 	}
 	itemName = elements[3]
-	bidAmount, err = strconv.ParseFloat(elements[4], 32)
+	bidAmount, err = model.ParseMoney(elements[4])
 	if err != nil {
 		return nil
 	}
+	// optional currency of the bid
+	if len(elements) == 6 {
+		currency, err = model.ParseCurrency(elements[5])
+		if err != nil {
+			return nil
+		}
+	}
 
 	return &model.BidCommand{
 		Timestamp: timestamp,
 		UserID:    int(userID),
 		ItemName:  itemName,
-		BidAmount: float32(bidAmount),
+		BidAmount: bidAmount,
+		Currency:  currency,
+	}
+}
+
+func toCancelCommand(elements []string) *model.CancelCommand {
+	// skipping element index 2 - action. Always CANCEL
+	timestamp, userID, err := parseTimestampAndUser(elements)
+	if err != nil {
+		return nil
+	}
+
+	return &model.CancelCommand{
+		Timestamp: timestamp,
+		UserID:    userID,
+		ItemName:  elements[3],
+	}
+}
+
+func toRetractCommand(elements []string) *model.RetractCommand {
+	// skipping element index 2 - action. Always RETRACT
+	timestamp, userID, err := parseTimestampAndUser(elements)
+	if err != nil {
+		return nil
 	}
+
+	return &model.RetractCommand{
+		Timestamp: timestamp,
+		UserID:    userID,
+		ItemName:  elements[3],
+	}
+}
+
+func toReserveCommand(elements []string) *model.ReserveCommand {
+	// skipping element index 2 - action. Always RESERVE
+	timestamp, userID, err := parseTimestampAndUser(elements)
+	if err != nil {
+		return nil
+	}
+	reservePrice, err := model.ParseMoney(elements[4])
+	if err != nil {
+		return nil
+	}
+
+	return &model.ReserveCommand{
+		Timestamp:    timestamp,
+		UserID:       userID,
+		ItemName:     elements[3],
+		ReservePrice: reservePrice,
+	}
+}
+
+func toDepositCommand(elements []string) *model.DepositCommand {
+	// skipping element index 2 - action. Always DEPOSIT
+	timestamp, userID, err := parseTimestampAndUser(elements)
+	if err != nil {
+		return nil
+	}
+	amount, err := model.ParseMoney(elements[4])
+	if err != nil {
+		return nil
+	}
+
+	return &model.DepositCommand{
+		Timestamp: timestamp,
+		UserID:    userID,
+		ItemName:  elements[3],
+		Amount:    amount,
+	}
+}
+
+func toPaymentCommand(elements []string) *model.PaymentCommand {
+	// skipping element index 2 - action. Always PAYMENT
+	timestamp, userID, err := parseTimestampAndUser(elements)
+	if err != nil {
+		return nil
+	}
+
+	outcome := model.OfferStatus(elements[4])
+	if outcome != model.OfferStatusPaid && outcome != model.OfferStatusDefaulted {
+		return nil
+	}
+
+	return &model.PaymentCommand{
+		Timestamp: timestamp,
+		UserID:    userID,
+		ItemName:  elements[3],
+		Outcome:   outcome,
+	}
+}
+
+// isAdminAction checks the action is one of the privileged actions
+func isAdminAction(action string) bool {
+	switch model.AdminAction(action) {
+	case model.AdminActionExtend, model.AdminActionForceClose, model.AdminActionVoid, model.AdminActionReopen,
+		model.AdminActionActivate, model.AdminActionSuspend, model.AdminActionBan,
+		model.AdminActionRefund, model.AdminActionSettle:
+		return true
+	default:
+		return false
+	}
+}
+
+// adminCommandLen returns the number of elements of the privileged command. EXTEND and REOPEN actions
+// have the new close time after the item, other actions end with the item or the target user
+func adminCommandLen(action model.AdminAction) int {
+	if action == model.AdminActionExtend || action == model.AdminActionReopen {
+		return 5
+	}
+
+	return 4
+}
+
+func toAdminCommand(elements []string) *model.AdminCommand {
+	timestamp, userID, err := parseTimestampAndUser(elements)
+	if err != nil {
+		return nil
+	}
+
+	cmd := &model.AdminCommand{
+		Timestamp: timestamp,
+		UserID:    userID,
+		Action:    model.AdminAction(elements[2]),
+	}
+
+	// user actions have the target user id instead of the item
+	switch cmd.Action {
+	case model.AdminActionActivate, model.AdminActionSuspend, model.AdminActionBan:
+		targetUserID, err := strconv.Atoi(elements[3])
+		if err != nil {
+			return nil
+		}
+		cmd.TargetUserID = targetUserID
+	default:
+		cmd.ItemName = elements[3]
+	}
+
+	// EXTEND and REOPEN actions have the new close time as the last element
+	if len(elements) == 5 {
+		cmd.CloseTime, err = strconv.ParseInt(elements[4], 10, 64)
+		if err != nil {
+			return nil
+		}
+	}
+
+	return cmd
+}
+
+// parseTimestampAndUser parses first two elements that are common for all user commands
+func parseTimestampAndUser(elements []string) (int64, int, error) {
+	timestamp, err := strconv.ParseInt(elements[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	userID, err := strconv.ParseInt(elements[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return timestamp, int(userID), nil
 }
 
 func toHeartbeatCommand(elements []string) *model.HeartbeatCommand {
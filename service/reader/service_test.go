@@ -0,0 +1,94 @@
+package reader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+func TestToSellCommand(t *testing.T) {
+	testCases := []struct {
+		name string
+		line string
+		exp  *model.SellCommand
+	}{
+		{
+			name: "success/no_options",
+			line: "10|1|SELL|phone|20.00|20",
+			exp:  &model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone", ReservePrice: 2000, CloseTime: 20},
+		},
+		{
+			name: "success/start_time",
+			line: "10|1|SELL|phone|20.00|20|start_time=12",
+			exp:  &model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone", ReservePrice: 2000, CloseTime: 20, StartTime: 12},
+		},
+		{
+			name: "success/starting_price",
+			line: "10|1|SELL|phone|20.00|20|starting_price=5.50",
+			exp:  &model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone", ReservePrice: 2000, CloseTime: 20, StartPrice: 550},
+		},
+		{
+			name: "success/currency",
+			line: "10|1|SELL|phone|20.00|20|currency=EUR",
+			exp:  &model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone", ReservePrice: 2000, CloseTime: 20, Currency: "EUR"},
+		},
+		{
+			name: "success/deposit_percent",
+			line: "10|1|SELL|phone|20.00|20|deposit_percent=10",
+			exp:  &model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone", ReservePrice: 2000, CloseTime: 20, DepositRate: 1000},
+		},
+		{
+			name: "success/all_options",
+			line: "10|1|SELL|phone|20.00|20|start_time=12|starting_price=5.50|currency=EUR|deposit_percent=10",
+			exp: &model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone", ReservePrice: 2000, CloseTime: 20,
+				StartTime: 12, StartPrice: 550, Currency: "EUR", DepositRate: 1000},
+		},
+		{
+			name: "success/any_order",
+			line: "10|1|SELL|phone|20.00|20|deposit_percent=10|currency=EUR|starting_price=5.50|start_time=12",
+			exp: &model.SellCommand{Timestamp: 10, UserID: 1, ItemName: "phone", ReservePrice: 2000, CloseTime: 20,
+				StartTime: 12, StartPrice: 550, Currency: "EUR", DepositRate: 1000},
+		},
+		{
+			name: "err/duplicate_key",
+			line: "10|1|SELL|phone|20.00|20|currency=EUR|currency=GBP",
+		},
+		{
+			name: "err/unknown_key",
+			line: "10|1|SELL|phone|20.00|20|buyout_price=50.00",
+		},
+		{
+			name: "err/missing_separator",
+			line: "10|1|SELL|phone|20.00|20|start_time",
+		},
+		{
+			name: "err/empty_option",
+			line: "10|1|SELL|phone|20.00|20|",
+		},
+		{
+			name: "err/start_time",
+			line: "10|1|SELL|phone|20.00|20|start_time=soon",
+		},
+		{
+			name: "err/starting_price",
+			line: "10|1|SELL|phone|20.00|20|starting_price=5.555",
+		},
+		{
+			name: "err/currency",
+			line: "10|1|SELL|phone|20.00|20|currency=EURO",
+		},
+		{
+			name: "err/deposit_percent",
+			line: "10|1|SELL|phone|20.00|20|deposit_percent=-10",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.exp, toSellCommand(strings.Split(tc.line, "|")))
+		})
+	}
+}
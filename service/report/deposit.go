@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// depositTemplate has format user_id|item|currency|amount|status|resolved_time
+const depositTemplate = "%d|%s|%s|%s|%s|%s"
+
+// DepositService reports deposits of bidders to stdout console: which are refunded, forfeited or still held.
+type DepositService struct {
+}
+
+func NewDeposit() *DepositService {
+	return &DepositService{}
+}
+
+// depositLine is the deposit of the user to the item
+type depositLine struct {
+	item     string
+	currency model.Currency
+	deposit  model.Deposit
+}
+
+// Report prints deposits grouped by user to stdout console by the template, in the order they were posted.
+// Empty currency means the reporting currency, empty resolved time means the deposit is still held.
+func (s *DepositService) Report(fos []model.ActionResult) error {
+	lines := make([]depositLine, 0)
+	for _, el := range fos {
+		for _, deposit := range el.Deposits {
+			lines = append(lines, depositLine{item: el.Item, currency: el.Currency, deposit: deposit})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].deposit.UserID != lines[j].deposit.UserID {
+			return lines[i].deposit.UserID < lines[j].deposit.UserID
+		}
+		return lines[i].deposit.Timestamp < lines[j].deposit.Timestamp
+	})
+
+	for _, el := range lines {
+		resolvedTime := ""
+		if el.deposit.Status != model.DepositStatusHeld {
+			resolvedTime = fmt.Sprintf("%d", el.deposit.ResolvedTime)
+		}
+		fmt.Printf(depositTemplate+"\n",
+			el.deposit.UserID,
+			el.item,
+			el.currency,
+			el.deposit.Amount,
+			el.deposit.Status,
+			resolvedTime,
+		)
+	}
+
+	return nil
+}
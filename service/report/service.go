@@ -2,18 +2,60 @@ package report
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/senseyman/auction-house/model"
 )
 
-const template = "%d|%s|%s|%s|%.2f|%d|%.2f|%.2f"
+const (
+	template = "%d|%s|%s|%s|%s|%d|%s|%s"
+
+	// openTimeTemplate is appended for scheduled auctions that started after their creation
+	openTimeTemplate = "|open_time=%d"
+	// reserveMetTemplate is appended for auctions with the starting price as their reserve is hidden
+	reserveMetTemplate = "|reserve_met=%t"
+	// reserveChangeTemplate is appended for auctions where the seller lowered the reserve price
+	reserveChangeTemplate = "|original_reserve=%s|final_reserve=%s"
+	// offersTemplate is appended for sold auctions with payment outcomes, lists offers in the order they were made
+	offersTemplate = "|offers=%s"
+	// offerTemplate formats the offer as user_id:price:status
+	offerTemplate = "%d:%s:%s"
+	// settlementStatusTemplate is appended for sold auctions moved past awaiting the payment
+	settlementStatusTemplate = "|settlement=%s"
+	// currencyTemplate is appended for auctions listed not in the reporting currency
+	currencyTemplate = "|currency=%s"
+	// reportingCurrencyTemplate is appended for auctions listed not in the reporting currency when rates are known
+	reportingCurrencyTemplate = "|reporting_currency=%s|reporting_price_paid=%s|reporting_highest_bid=%s|reporting_lowest_bid=%s"
+)
+
+// CurrencyConverter converts auction amounts to the site's reporting currency
+type CurrencyConverter interface {
+	Convert(amount model.Money, from, to model.Currency, timestamp int64) (model.Money, error)
+	ReportingCurrency() model.Currency
+}
 
 // Service reports auction results to stdout console.
 type Service struct {
+	converter CurrencyConverter
+}
+
+// Option configures optional parameters of the service
+type Option func(s *Service)
+
+// WithCurrencyConverter sets the converter for reporting amounts in the reporting currency
+func WithCurrencyConverter(converter CurrencyConverter) Option {
+	return func(s *Service) {
+		s.converter = converter
+	}
 }
 
-func New() *Service {
-	return &Service{}
+func New(opts ...Option) *Service {
+	s := &Service{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Report prints results to stdout console by the template.
@@ -29,6 +71,27 @@ func (s *Service) Report(fos []model.ActionResult) error {
 			el.Statistics.HighestBid,
 			el.Statistics.LowestBid,
 		)
+		if el.StartTime > el.CreationTime {
+			res += fmt.Sprintf(openTimeTemplate, el.StartTime)
+		}
+		if el.StartPrice > 0 {
+			res += fmt.Sprintf(reserveMetTemplate, el.ReserveMet)
+		}
+		if el.OriginalReservePrice != el.FinalReservePrice {
+			res += fmt.Sprintf(reserveChangeTemplate, el.OriginalReservePrice, el.FinalReservePrice)
+		}
+		if hasPaymentOutcome(el.Offers) {
+			res += fmt.Sprintf(offersTemplate, formatOffers(el.Offers))
+		}
+		if el.SettlementStatus != "" && el.SettlementStatus != model.SettlementStatusAwaitingPayment {
+			res += fmt.Sprintf(settlementStatusTemplate, el.SettlementStatus)
+		}
+
+		currencyRes, err := s.formatCurrency(el)
+		if err != nil {
+			return err
+		}
+		res += currencyRes
 
 		fmt.Println(res)
 	}
@@ -36,6 +99,52 @@ func (s *Service) Report(fos []model.ActionResult) error {
 	return nil
 }
 
+// formatCurrency formats the native currency and amounts in the reporting currency
+// for auctions listed not in the reporting currency
+func (s *Service) formatCurrency(el model.ActionResult) (string, error) {
+	if el.Currency == "" || (s.converter != nil && el.Currency == s.converter.ReportingCurrency()) {
+		return "", nil
+	}
+
+	res := fmt.Sprintf(currencyTemplate, el.Currency)
+	if s.converter == nil {
+		return res, nil
+	}
+
+	// amounts are converted by the rates in effect at the auction close
+	amounts := []model.Money{el.PricePaid, el.Statistics.HighestBid, el.Statistics.LowestBid}
+	for idx := range amounts {
+		converted, err := s.converter.Convert(amounts[idx], el.Currency, s.converter.ReportingCurrency(), el.CloseTime)
+		if err != nil {
+			return "", err
+		}
+		amounts[idx] = converted
+	}
+
+	return res + fmt.Sprintf(reportingCurrencyTemplate, s.converter.ReportingCurrency(), amounts[0], amounts[1], amounts[2]), nil
+}
+
+// hasPaymentOutcome checks any buyer has paid or defaulted
+func hasPaymentOutcome(offers []model.Offer) bool {
+	for _, offer := range offers {
+		if offer.Status != model.OfferStatusPending {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatOffers formats the chain of offers
+func formatOffers(offers []model.Offer) string {
+	res := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		res = append(res, fmt.Sprintf(offerTemplate, offer.UserID, offer.Price, offer.Status))
+	}
+
+	return strings.Join(res, ",")
+}
+
 func digitOrEmpty(el int) string {
 	if el == 0 {
 		return ""
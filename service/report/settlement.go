@@ -0,0 +1,48 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// settlementTemplate has format
+// close_time|item|seller_id|buyer_id|currency|hammer_price|buyer_premium|buyer_total|seller_commission|seller_payout|settlement_status
+const settlementTemplate = "%d|%s|%d|%d|%s|%s|%s|%s|%s|%s|%s"
+
+// SettlementService reports money flows of sold auctions to stdout console:
+// what the buyer pays, what the seller receives and the auction house fees.
+type SettlementService struct {
+}
+
+func NewSettlement() *SettlementService {
+	return &SettlementService{}
+}
+
+// Report prints settlement of sold auctions to stdout console by the template.
+// Empty currency means the reporting currency.
+func (s *SettlementService) Report(fos []model.ActionResult) error {
+	for _, el := range fos {
+		if el.Status != model.OrderStatusSold {
+			continue
+		}
+
+		res := fmt.Sprintf(settlementTemplate,
+			el.CloseTime,
+			el.Item,
+			el.SellerID,
+			el.UserID,
+			el.Currency,
+			el.PricePaid,
+			el.BuyerPremium,
+			el.PricePaid+el.BuyerPremium,
+			el.SellerCommission,
+			el.PricePaid-el.SellerCommission,
+			el.SettlementStatus,
+		)
+
+		fmt.Println(res)
+	}
+
+	return nil
+}
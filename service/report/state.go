@@ -0,0 +1,47 @@
+package report
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// auctionStateTemplate has format timestamp|item|leader_id|status|price|bid_count
+const auctionStateTemplate = "%d|%s|%s|%s|%s|%d"
+
+// AuctionStateReader provides the state of the auction as of the time
+type AuctionStateReader interface {
+	GetOrderAt(ctx context.Context, itemName string, timestamp int64) (model.AuctionState, error)
+}
+
+// AuctionStateService reports the state of one auction as of the time to stdout console
+// instead of the auction results, once all commands are processed.
+type AuctionStateService struct {
+	reader    AuctionStateReader
+	item      string
+	timestamp int64
+}
+
+func NewAuctionState(reader AuctionStateReader, item string, timestamp int64) *AuctionStateService {
+	return &AuctionStateService{reader: reader, item: item, timestamp: timestamp}
+}
+
+// Report prints the state of the auction by the template, empty leader means there are no bids or no buyer
+func (s *AuctionStateService) Report(_ []model.ActionResult) error {
+	state, err := s.reader.GetOrderAt(context.Background(), s.item, s.timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to get state of %s at %d: %w", s.item, s.timestamp, err)
+	}
+
+	fmt.Printf(auctionStateTemplate+"\n",
+		state.Timestamp,
+		state.Item,
+		digitOrEmpty(state.LeaderID),
+		state.Status,
+		state.Price,
+		state.BidCount,
+	)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// trialBalanceTemplate has format account|currency|debit|credit
+const trialBalanceTemplate = "%s|%s|%s|%s"
+
+// totalAccount is the name of the line with the totals of the currency
+const totalAccount = "TOTAL"
+
+// Ledger provides account balances and checks they match auction results
+type Ledger interface {
+	TrialBalance() []model.LedgerBalance
+	Reconcile(result model.ActionResult) error
+}
+
+// TrialBalanceService reports ledger account balances to stdout console
+// after checking every auction result reconciles with the ledger.
+type TrialBalanceService struct {
+	ledger Ledger
+}
+
+func NewTrialBalance(ledger Ledger) *TrialBalanceService {
+	return &TrialBalanceService{ledger: ledger}
+}
+
+// Report prints not zero account balances sorted by currency and account,
+// followed by debit and credit totals of every currency. Empty currency means the reporting currency.
+func (s *TrialBalanceService) Report(fos []model.ActionResult) error {
+	for _, el := range fos {
+		if err := s.ledger.Reconcile(el); err != nil {
+			return err
+		}
+	}
+
+	balances := s.ledger.TrialBalance()
+	for idx, el := range balances {
+		fmt.Printf(trialBalanceTemplate+"\n", el.Account, el.Currency, el.Debit, el.Credit)
+		if idx+1 < len(balances) && balances[idx+1].Currency == el.Currency {
+			continue
+		}
+
+		// the last account of the currency
+		total := model.LedgerBalance{Account: totalAccount, Currency: el.Currency}
+		for _, balance := range balances {
+			if balance.Currency == el.Currency {
+				total.Debit += balance.Debit
+				total.Credit += balance.Credit
+			}
+		}
+		fmt.Printf(trialBalanceTemplate+"\n", total.Account, total.Currency, total.Debit, total.Credit)
+	}
+
+	return nil
+}
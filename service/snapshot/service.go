@@ -0,0 +1,147 @@
+// Package snapshot provides periodic snapshots of the in-memory state for the crash recovery
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/ledger"
+	"github.com/senseyman/auction-house/storage/inmemory"
+)
+
+// Storage provides the state of all orders
+type Storage interface {
+	Snapshot() []inmemory.OrderState
+	Restore(states []inmemory.OrderState) error
+}
+
+// Ledger provides posted entries
+type Ledger interface {
+	Entries() []ledger.Entry
+	Restore(entries []ledger.Entry) error
+}
+
+// UserService provides registered users changed by admin commands
+type UserService interface {
+	Users() []model.User
+	Restore(users []model.User)
+}
+
+// Snapshot is the state of services after processing the command with the sequence number
+type Snapshot struct {
+	Seq    int64
+	Orders []inmemory.OrderState
+	Ledger []ledger.Entry
+	Users  []model.User
+}
+
+// Service saves the state of services to the snapshot file and restores it.
+type Service struct {
+	path        string
+	storage     Storage
+	ledger      Ledger
+	userService UserService
+}
+
+// Option configures optional parameters of the service
+type Option func(s *Service)
+
+// WithLedger sets the ledger saved to the snapshot
+func WithLedger(ledger Ledger) Option {
+	return func(s *Service) {
+		s.ledger = ledger
+	}
+}
+
+// WithUserService sets the users registry saved to the snapshot
+func WithUserService(userService UserService) Option {
+	return func(s *Service) {
+		s.userService = userService
+	}
+}
+
+func New(path string, storage Storage, opts ...Option) *Service {
+	s := &Service{
+		path:    path,
+		storage: storage,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Save writes the snapshot of the state after processing the command with the sequence number.
+// The previous snapshot is replaced only when the new one is completely written
+func (s *Service) Save(seq int64) error {
+	snapshot := Snapshot{
+		Seq:    seq,
+		Orders: s.storage.Snapshot(),
+	}
+	if s.ledger != nil {
+		snapshot.Ledger = s.ledger.Entries()
+	}
+	if s.userService != nil {
+		snapshot.Users = s.userService.Users()
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.path)
+}
+
+// Load restores the state of services from the snapshot and returns the sequence number of the last command in it.
+// It returns 0 if there is no snapshot yet
+func (s *Service) Load() (int64, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var snapshot Snapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return 0, err
+	}
+
+	if err = s.storage.Restore(snapshot.Orders); err != nil {
+		return 0, err
+	}
+	if s.ledger != nil {
+		if err = s.ledger.Restore(snapshot.Ledger); err != nil {
+			return 0, err
+		}
+	}
+	if s.userService != nil {
+		s.userService.Restore(snapshot.Users)
+	}
+
+	return snapshot.Seq, nil
+}
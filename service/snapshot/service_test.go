@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/ledger"
+	"github.com/senseyman/auction-house/service/user"
+	"github.com/senseyman/auction-house/storage/inmemory"
+)
+
+func TestService_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	// there is no snapshot yet
+	seq, err := New(path, inmemory.New()).Load()
+	assert.NoError(t, err)
+	assert.Zero(t, seq)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = New(path, inmemory.New()).Load()
+	assert.Error(t, err)
+}
+
+func TestService_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	ledgerService := ledger.New()
+	userService := user.New()
+	storage := inmemory.New(inmemory.WithLedger(ledgerService))
+	assert.NoError(t, storage.CreateOrder(context.TODO(), model.Order{
+		Item:         model.Item{Name: "phone_1", ReservePrice: 1000},
+		Status:       model.OrderStatusInit,
+		SellerID:     1,
+		CreationTime: 10,
+		CloseTime:    20,
+	}))
+	assert.NoError(t, storage.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 1500}))
+	assert.NoError(t, storage.FinishExpiredAuctions(context.TODO(), 21))
+	assert.NoError(t, userService.SetStatus(3, model.UserStatusSuspended))
+
+	s := New(path, storage, WithLedger(ledgerService), WithUserService(userService))
+	assert.NoError(t, s.Save(5))
+	assert.NoError(t, s.Save(7))
+
+	// only the snapshot file is left
+	files, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	restoredLedger := ledger.New()
+	restoredUsers := user.New()
+	restoredStorage := inmemory.New(inmemory.WithLedger(restoredLedger))
+	seq, err := New(path, restoredStorage, WithLedger(restoredLedger), WithUserService(restoredUsers)).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), seq)
+	assert.Equal(t, storage.Snapshot(), restoredStorage.Snapshot())
+	assert.Equal(t, ledgerService.TrialBalance(), restoredLedger.TrialBalance())
+	assert.NotEmpty(t, restoredLedger.TrialBalance())
+	assert.Equal(t, userService.Users(), restoredUsers.Users())
+	assert.ErrorIs(t, restoredUsers.CheckBuyer(3), model.ErrUserSuspended)
+}
@@ -0,0 +1,242 @@
+package user
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// defaultRoles are roles of users without explicitly set roles
+var defaultRoles = []model.UserRole{model.UserRoleBuyer, model.UserRoleSeller}
+
+// Service is the registry of user accounts loaded at startup and managed by admin commands.
+type Service struct {
+	mx sync.RWMutex
+
+	users         map[int]*model.User // key - user id
+	rejectUnknown bool
+}
+
+// Option configures optional parameters of the service
+type Option func(s *Service)
+
+// WithUnknownUsersRejected makes users missing in the registry not eligible for any action
+func WithUnknownUsersRejected() Option {
+	return func(s *Service) {
+		s.rejectUnknown = true
+	}
+}
+
+func New(opts ...Option) *Service {
+	s := &Service{
+		users: make(map[int]*model.User),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Load reads user accounts from the file.
+// Every line has format `user_id|credit_limit|status|roles`, where
+// empty credit limit means the user has no limit,
+// optional status is ACTIVE by default,
+// optional roles are comma separated list of BUYER and SELLER, both by default.
+func (s *Service) Load(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	fileScanner := bufio.NewScanner(file)
+	fileScanner.Split(bufio.ScanLines)
+
+	lineNum := 0
+	for fileScanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(fileScanner.Text())
+		if line == "" {
+			continue
+		}
+
+		user, err := parseUser(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		s.users[user.ID] = user
+	}
+
+	return fileScanner.Err()
+}
+
+// GetCreditLimit returns the credit limit of the user. False means the user has no limit
+func (s *Service) GetCreditLimit(userID int) (model.Money, bool) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	user, ok := s.users[userID]
+	if !ok || !user.HasCreditLimit {
+		return 0, false
+	}
+
+	return user.CreditLimit, true
+}
+
+// CheckSeller checks the user is eligible to list items
+func (s *Service) CheckSeller(userID int) error {
+	return s.checkUser(userID, model.UserRoleSeller)
+}
+
+// CheckBuyer checks the user is eligible to bid
+func (s *Service) CheckBuyer(userID int) error {
+	return s.checkUser(userID, model.UserRoleBuyer)
+}
+
+// SetStatus changes the status of the user. Unknown user is registered with default roles.
+// Banned user can't change the status anymore.
+func (s *Service) SetStatus(userID int, status model.UserStatus) error {
+	if !isValidStatus(status) {
+		return model.ErrInvalidData
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		s.users[userID] = &model.User{ID: userID, Status: status, Roles: defaultRoles}
+		return nil
+	}
+
+	if user.Status == model.UserStatusBanned {
+		return model.ErrUserBanned
+	}
+	user.Status = status
+
+	return nil
+}
+
+// Users returns registered users sorted by id
+func (s *Service) Users() []model.User {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	res := make([]model.User, 0, len(s.users))
+	for _, user := range s.users {
+		copied := *user
+		copied.Roles = append([]model.UserRole(nil), user.Roles...)
+		res = append(res, copied)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res
+}
+
+// Restore replaces registered users
+func (s *Service) Restore(users []model.User) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.users = make(map[int]*model.User, len(users))
+	for idx := range users {
+		user := users[idx]
+		user.Roles = append([]model.UserRole(nil), user.Roles...)
+		s.users[user.ID] = &user
+	}
+}
+
+// checkUser checks the user is active and has the role
+func (s *Service) checkUser(userID int, role model.UserRole) error {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		if s.rejectUnknown {
+			return model.ErrUnknownUser
+		}
+		return nil
+	}
+
+	switch user.Status {
+	case model.UserStatusSuspended:
+		return model.ErrUserSuspended
+	case model.UserStatusBanned:
+		return model.ErrUserBanned
+	}
+
+	if !user.HasRole(role) {
+		return model.ErrUserRoleNotAllowed
+	}
+
+	return nil
+}
+
+// parseUser parses one line of the user accounts file
+func parseUser(line string) (*model.User, error) {
+	elements := strings.Split(line, "|")
+	if len(elements) < 2 || len(elements) > 4 {
+		return nil, model.ErrInvalidData
+	}
+
+	userID, err := strconv.Atoi(strings.TrimSpace(elements[0]))
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		ID:     userID,
+		Status: model.UserStatusActive,
+		Roles:  defaultRoles,
+	}
+
+	if creditLimit := strings.TrimSpace(elements[1]); creditLimit != "" {
+		user.CreditLimit, err = model.ParseMoney(creditLimit)
+		if err != nil {
+			return nil, err
+		}
+		user.HasCreditLimit = true
+	}
+
+	if len(elements) >= 3 && strings.TrimSpace(elements[2]) != "" {
+		user.Status = model.UserStatus(strings.ToUpper(strings.TrimSpace(elements[2])))
+		if !isValidStatus(user.Status) {
+			return nil, fmt.Errorf("%w: status %q", model.ErrInvalidData, elements[2])
+		}
+	}
+
+	if len(elements) == 4 && strings.TrimSpace(elements[3]) != "" {
+		user.Roles = nil
+		for _, el := range strings.Split(elements[3], ",") {
+			role := model.UserRole(strings.ToUpper(strings.TrimSpace(el)))
+			if role != model.UserRoleBuyer && role != model.UserRoleSeller {
+				return nil, fmt.Errorf("%w: role %q", model.ErrInvalidData, el)
+			}
+			user.Roles = append(user.Roles, role)
+		}
+	}
+
+	return user, nil
+}
+
+func isValidStatus(status model.UserStatus) bool {
+	switch status {
+	case model.UserStatusActive, model.UserStatusSuspended, model.UserStatusBanned:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,170 @@
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+func TestNew(t *testing.T) {
+	assert.NotNil(t, New())
+}
+
+func TestService_Load(t *testing.T) {
+	testCases := []struct {
+		name   string
+		data   string
+		hasErr bool
+	}{
+		{
+			name: "success",
+			data: "1|100.00\n\n2|\n3||suspended\n4|||buyer, SELLER\n5|10|BANNED|SELLER\n",
+		},
+		{
+			name:   "err/status",
+			data:   "1||DELETED\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/role",
+			data:   "1|||ADMIN\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/too_many_elements",
+			data:   "1|||BUYER|1\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/format",
+			data:   "1\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/user_id",
+			data:   "a|100.00\n",
+			hasErr: true,
+		},
+		{
+			name:   "err/credit_limit",
+			data:   "1|1.001\n",
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := New().Load(writeFile(t, tc.data))
+
+			if tc.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.Error(t, New().Load(filepath.Join(t.TempDir(), "missing.txt")))
+}
+
+func TestService_GetCreditLimit(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.Load(writeFile(t, "1|100.50\n2|\n")))
+
+	limit, ok := s.GetCreditLimit(1)
+	assert.True(t, ok)
+	assert.Equal(t, model.Money(10050), limit)
+
+	_, ok = s.GetCreditLimit(2) // user without limit
+	assert.False(t, ok)
+
+	_, ok = s.GetCreditLimit(3) // unknown user
+	assert.False(t, ok)
+}
+
+func writeFile(t *testing.T, data string) string {
+	filename := filepath.Join(t.TempDir(), "users.txt")
+	assert.NoError(t, os.WriteFile(filename, []byte(data), 0o600))
+
+	return filename
+}
+
+func TestService_CheckUser(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.Load(writeFile(t, "1|\n2||SUSPENDED\n3||BANNED\n4|||BUYER\n5|||SELLER\n")))
+
+	testCases := []struct {
+		name      string
+		userID    int
+		sellerErr error
+		buyerErr  error
+	}{
+		{name: "active", userID: 1},
+		{name: "unknown", userID: 10},
+		{name: "suspended", userID: 2, sellerErr: model.ErrUserSuspended, buyerErr: model.ErrUserSuspended},
+		{name: "banned", userID: 3, sellerErr: model.ErrUserBanned, buyerErr: model.ErrUserBanned},
+		{name: "buyer", userID: 4, sellerErr: model.ErrUserRoleNotAllowed},
+		{name: "seller", userID: 5, buyerErr: model.ErrUserRoleNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, s.CheckSeller(tc.userID), tc.sellerErr)
+			assert.ErrorIs(t, s.CheckBuyer(tc.userID), tc.buyerErr)
+		})
+	}
+
+	// unknown users are rejected by the strict registry
+	strict := New(WithUnknownUsersRejected())
+	assert.ErrorIs(t, strict.CheckSeller(10), model.ErrUnknownUser)
+	assert.ErrorIs(t, strict.CheckBuyer(10), model.ErrUnknownUser)
+}
+
+func TestService_SetStatus(t *testing.T) {
+	s := New(WithUnknownUsersRejected())
+	assert.NoError(t, s.Load(writeFile(t, "1|\n2|||BUYER\n")))
+
+	// suspend and activate again
+	assert.NoError(t, s.SetStatus(1, model.UserStatusSuspended))
+	assert.ErrorIs(t, s.CheckBuyer(1), model.ErrUserSuspended)
+	assert.NoError(t, s.SetStatus(1, model.UserStatusActive))
+	assert.NoError(t, s.CheckBuyer(1))
+
+	// ban is permanent
+	assert.NoError(t, s.SetStatus(2, model.UserStatusBanned))
+	assert.ErrorIs(t, s.SetStatus(2, model.UserStatusActive), model.ErrUserBanned)
+	assert.ErrorIs(t, s.CheckBuyer(2), model.ErrUserBanned)
+
+	// unknown user is registered with default roles
+	assert.NoError(t, s.SetStatus(3, model.UserStatusActive))
+	assert.NoError(t, s.CheckSeller(3))
+	assert.NoError(t, s.CheckBuyer(3))
+
+	assert.ErrorIs(t, s.SetStatus(1, "DELETED"), model.ErrInvalidData)
+}
+
+func TestService_Restore(t *testing.T) {
+	s := New(WithUnknownUsersRejected())
+	assert.NoError(t, s.Load(writeFile(t, "2|100.00||BUYER\n1|\n")))
+	assert.NoError(t, s.SetStatus(1, model.UserStatusSuspended))
+
+	users := s.Users()
+	assert.Len(t, users, 2)
+	assert.Equal(t, 1, users[0].ID)
+	assert.Equal(t, model.UserStatusSuspended, users[0].Status)
+
+	restored := New(WithUnknownUsersRejected())
+	restored.Restore(users)
+	assert.Equal(t, users, restored.Users())
+	assert.ErrorIs(t, restored.CheckBuyer(1), model.ErrUserSuspended)
+	assert.ErrorIs(t, restored.CheckSeller(2), model.ErrUserRoleNotAllowed)
+	assert.ErrorIs(t, restored.CheckBuyer(3), model.ErrUnknownUser)
+
+	// returned users don't share roles with the registry
+	users[1].Roles[0] = model.UserRoleSeller
+	assert.ErrorIs(t, restored.CheckSeller(2), model.ErrUserRoleNotAllowed)
+}
@@ -0,0 +1,187 @@
+// Package wal provides the append-only log of accepted commands for the crash recovery
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// headerSize is the size of the record header: the payload length and its checksum
+const headerSize = 8
+
+// maxRecordSize limits the payload length read from the header of the damaged record
+const maxRecordSize = 1 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Service is the append-only log of commands. Every record is the JSON encoded command
+// prefixed by its length and checksum, so the record torn by the crash is detected and cut off on open.
+type Service struct {
+	mx sync.Mutex
+
+	file   *os.File
+	noSync bool
+}
+
+// Option configures optional parameters of the log
+type Option func(s *Service)
+
+// WithoutSync disables flushing every record to the disk. Records may be lost on the OS crash, not on the process crash
+func WithoutSync() Option {
+	return func(s *Service) {
+		s.noSync = true
+	}
+}
+
+// New opens the log file, creating it if needed. The damaged tail of the log is cut off
+func New(path string, opts ...Option) (*Service, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{file: file}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	validSize, err := s.scan(func(model.Command) error { return nil })
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err = file.Truncate(validSize); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err = file.Seek(validSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Append writes the command to the end of the log
+func (s *Service) Append(cmd model.Command) error {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, headerSize, headerSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.Checksum(payload, crcTable))
+	record = append(record, payload...)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if _, err = s.file.Write(record); err != nil {
+		return err
+	}
+	if s.noSync {
+		return nil
+	}
+
+	return s.file.Sync()
+}
+
+// Replay calls the function for commands of the log in the order they were written, skipping commands up to the sequence number
+func (s *Service) Replay(after int64, fn func(cmd model.Command) error) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	_, err := s.scan(func(cmd model.Command) error {
+		if cmd.Seq <= after {
+			return nil
+		}
+		return fn(cmd)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.file.Seek(0, io.SeekEnd)
+
+	return err
+}
+
+// Truncate removes all commands from the log, it's called when they are saved in the snapshot
+func (s *Service) Truncate() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := s.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if s.noSync {
+		return nil
+	}
+
+	return s.file.Sync()
+}
+
+// Close closes the log file
+func (s *Service) Close() error {
+	return s.file.Close()
+}
+
+// scan reads valid records from the start of the log and returns the size of the valid part
+func (s *Service) scan(fn func(cmd model.Command) error) (int64, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var (
+		reader = bufio.NewReader(s.file)
+		header = make([]byte, headerSize)
+		offset int64
+	)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return offset, ignoreTornRecord(err)
+		}
+
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size > maxRecordSize {
+			return offset, nil
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return offset, ignoreTornRecord(err)
+		}
+		if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+			return offset, nil
+		}
+
+		var cmd model.Command
+		if err := json.Unmarshal(payload, &cmd); err != nil {
+			return offset, nil
+		}
+		if err := fn(cmd); err != nil {
+			return offset, err
+		}
+		offset += headerSize + int64(size)
+	}
+}
+
+// ignoreTornRecord treats the end of the log in the middle of the record as the end of the log
+func ignoreTornRecord(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+
+	return err
+}
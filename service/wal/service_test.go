@@ -0,0 +1,151 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "wal.log"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Close())
+
+	_, err = New(filepath.Join(t.TempDir(), "missing", "wal.log"))
+	assert.Error(t, err)
+}
+
+func TestService_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+	commands := generateCommands(3)
+
+	s, err := New(path, WithoutSync())
+	assert.NoError(t, err)
+	for _, cmd := range commands {
+		assert.NoError(t, s.Append(cmd))
+	}
+	assert.Equal(t, commands, replay(t, s, 0))
+	assert.Equal(t, commands[2:], replay(t, s, 2))
+
+	// appending continues after the replay
+	assert.NoError(t, s.Append(model.Command{Type: model.CommandTypeHeartbeat, Seq: 4}))
+	assert.Len(t, replay(t, s, 0), 4)
+	assert.NoError(t, s.Close())
+
+	// the log is read back after reopening
+	s, err = New(path)
+	assert.NoError(t, err)
+	assert.Len(t, replay(t, s, 0), 4)
+	assert.NoError(t, s.Close())
+}
+
+func TestService_DamagedTail(t *testing.T) {
+	testCases := []struct {
+		name   string
+		damage func(data []byte) []byte
+		expLen int
+	}{
+		{
+			name: "torn_header",
+			damage: func(data []byte) []byte {
+				return append(data, 0, 0, 1)
+			},
+			expLen: 2,
+		},
+		{
+			name: "torn_payload",
+			damage: func(data []byte) []byte {
+				return data[:len(data)-3]
+			},
+			expLen: 1,
+		},
+		{
+			name: "checksum",
+			damage: func(data []byte) []byte {
+				data[len(data)-2] ^= 0xff
+				return data
+			},
+			expLen: 1,
+		},
+		{
+			name: "length",
+			damage: func(data []byte) []byte {
+				return append(data, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0)
+			},
+			expLen: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wal.log")
+			s, err := New(path, WithoutSync())
+			assert.NoError(t, err)
+			for _, cmd := range generateCommands(2) {
+				assert.NoError(t, s.Append(cmd))
+			}
+			assert.NoError(t, s.Close())
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.NoError(t, os.WriteFile(path, tc.damage(data), 0o600))
+
+			s, err = New(path, WithoutSync())
+			assert.NoError(t, err)
+			assert.Len(t, replay(t, s, 0), tc.expLen)
+
+			// new records follow the valid part
+			assert.NoError(t, s.Append(model.Command{Type: model.CommandTypeHeartbeat, Seq: 3}))
+			assert.Len(t, replay(t, s, 0), tc.expLen+1)
+			assert.NoError(t, s.Close())
+		})
+	}
+}
+
+func TestService_Truncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+	s, err := New(path)
+	assert.NoError(t, err)
+	for _, cmd := range generateCommands(2) {
+		assert.NoError(t, s.Append(cmd))
+	}
+
+	assert.NoError(t, s.Truncate())
+	assert.Empty(t, replay(t, s, 0))
+
+	assert.NoError(t, s.Append(model.Command{Type: model.CommandTypeHeartbeat, Seq: 3}))
+	assert.Equal(t, []model.Command{{Type: model.CommandTypeHeartbeat, Seq: 3}}, replay(t, s, 0))
+	assert.NoError(t, s.Close())
+}
+
+func replay(t *testing.T, s *Service, after int64) []model.Command {
+	var res []model.Command
+	assert.NoError(t, s.Replay(after, func(cmd model.Command) error {
+		res = append(res, cmd)
+		return nil
+	}))
+
+	return res
+}
+
+func generateCommands(num int) []model.Command {
+	res := make([]model.Command, 0, num)
+	for i := 1; i <= num; i++ {
+		res = append(res, model.Command{
+			Type: model.CommandTypeBid,
+			Seq:  int64(i),
+			Bid: &model.BidCommand{
+				Timestamp: int64(10 + i),
+				UserID:    3,
+				ItemName:  "phone_1",
+				BidAmount: model.Money(1000 * i),
+			},
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,147 @@
+// Package archive provides cold stores of closed auctions moved out of the memory of the storage
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/inmemory"
+)
+
+// File appends archived orders to the file, one JSON encoded order state per line.
+// Only offsets of the latest state of every order are kept in memory, states are read from the file on demand
+type File struct {
+	mx sync.Mutex
+
+	file    *os.File
+	offsets map[string]int64 // offsets of the latest states, key - order name
+	size    int64
+}
+
+// NewFile opens the archive file, creating it if needed. The line torn by the crash is cut off
+func NewFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		file:    file,
+		offsets: make(map[string]int64),
+	}
+	if err = f.scan(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err = file.Truncate(f.size); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Put appends the state of the order, it replaces the state archived before
+func (f *File) Put(_ context.Context, state inmemory.OrderState) error {
+	line, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if _, err = f.file.WriteAt(line, f.size); err != nil {
+		return err
+	}
+	f.offsets[state.Order.Item.Name] = f.size
+	f.size += int64(len(line))
+
+	return nil
+}
+
+// Get reads the latest archived state of the order
+func (f *File) Get(_ context.Context, orderName string) (inmemory.OrderState, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	offset, ok := f.offsets[orderName]
+	if !ok {
+		return inmemory.OrderState{}, model.ErrNotFound
+	}
+
+	return f.read(offset)
+}
+
+// Results reads results of all archived orders sorted by order name
+func (f *File) Results(_ context.Context) ([]model.ActionResult, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	names := make([]string, 0, len(f.offsets))
+	for name := range f.offsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]model.ActionResult, 0, len(names))
+	for _, name := range names {
+		state, err := f.read(f.offsets[name])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, state.Result)
+	}
+
+	return results, nil
+}
+
+// Close closes the archive file
+func (f *File) Close() error {
+	return f.file.Close()
+}
+
+// read decodes the state written at the offset
+func (f *File) read(offset int64) (inmemory.OrderState, error) {
+	line, err := bufio.NewReader(io.NewSectionReader(f.file, offset, f.size-offset)).ReadBytes('\n')
+	if err != nil {
+		return inmemory.OrderState{}, err
+	}
+
+	var state inmemory.OrderState
+	if err = json.Unmarshal(line, &state); err != nil {
+		return inmemory.OrderState{}, err
+	}
+
+	return state, nil
+}
+
+// scan reads complete lines from the start of the file and indexes them
+func (f *File) scan() error {
+	reader := bufio.NewReader(f.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// the incomplete line is torn by the crash
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var state inmemory.OrderState
+		if err = json.Unmarshal(line, &state); err != nil {
+			return nil
+		}
+		f.offsets[state.Order.Item.Name] = f.size
+		f.size += int64(len(line))
+	}
+}
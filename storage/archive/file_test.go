@@ -0,0 +1,95 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/auction"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestNewFile(t *testing.T) {
+	f, err := NewFile(filepath.Join(t.TempDir(), "archive.jsonl"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	_, err = NewFile(filepath.Join(t.TempDir(), "missing", "archive.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestFile_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) auction.Storage {
+		f, err := NewFile(filepath.Join(t.TempDir(), "archive.jsonl"))
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+
+		return inmemory.New(inmemory.WithArchive(f, 0))
+	})
+}
+
+func TestFile_Get(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	states := generateStates(t, 2)
+
+	f, err := NewFile(path)
+	require.NoError(t, err)
+	for _, state := range states {
+		assert.NoError(t, f.Put(context.TODO(), state))
+	}
+
+	state, err := f.Get(context.TODO(), "phone_1")
+	assert.NoError(t, err)
+	assert.Equal(t, states[0], state)
+	_, err = f.Get(context.TODO(), "tv_1")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// the state archived again replaces the previous one
+	states[0].Order.SettlementStatus = model.SettlementStatusSettled
+	states[0].Result.SettlementStatus = model.SettlementStatusSettled
+	assert.NoError(t, f.Put(context.TODO(), states[0]))
+	state, err = f.Get(context.TODO(), "phone_1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.SettlementStatusSettled, state.Order.SettlementStatus)
+	assert.NoError(t, f.Close())
+
+	// states are read back after reopening, the torn line is cut off
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(data, `{"Order":`...), 0o600))
+
+	f, err = NewFile(path)
+	require.NoError(t, err)
+	results, err := f.Results(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ActionResult{states[0].Result, states[1].Result}, results)
+
+	assert.NoError(t, f.Put(context.TODO(), states[1]))
+	state, err = f.Get(context.TODO(), "phone_2")
+	assert.NoError(t, err)
+	assert.Equal(t, states[1], state)
+	assert.NoError(t, f.Close())
+}
+
+// generateStates returns states of closed orders phone_1, phone_2, ...
+func generateStates(t *testing.T, num int) []inmemory.OrderState {
+	s := inmemory.New()
+	for _, order := range storagetest.GenerateOrders(num) {
+		require.NoError(t, s.CreateOrder(context.TODO(), order))
+		require.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{
+			Timestamp: order.CreationTime,
+			UserID:    3,
+			ItemName:  order.Item.Name,
+			BidAmount: 2500,
+		}))
+	}
+	require.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	return s.Snapshot()
+}
@@ -0,0 +1,264 @@
+// Package bolt provides the auction storage persisted to the embedded bbolt key-value file.
+// Every call is saved in one transaction, the state is loaded back when the file is opened.
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/writethrough"
+)
+
+// list of buckets
+var (
+	bucketOrders     = []byte("orders")      // key - order name, value - order
+	bucketBids       = []byte("bids")        // bucket per order name, key - sequence number, value - action of the auction history
+	bucketCloseIndex = []byte("close_index") // key - close time and name of the open order
+	bucketLedger     = []byte("ledger")      // key - sequence number, value - ledger event
+)
+
+// openTimeout is the time to wait for the file lock held by another process
+const openTimeout = time.Second
+
+// Storage stores auction data in the bbolt file.
+type Storage struct {
+	*writethrough.Storage
+
+	db *bbolt.DB
+}
+
+// New opens the file, creating it if needed, and loads the saved auctions.
+// Options configure auction policies the same way as for the in-memory storage
+func New(ctx context.Context, path string, opts ...inmemory.Option) (*Storage, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{bucketOrders, bucketBids, bucketCloseIndex, bucketLedger} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	storage, err := writethrough.New(ctx, store{db: db}, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Storage{
+		Storage: storage,
+		db:      db,
+	}, nil
+}
+
+// Close closes the file
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// FinishExpiredAuctions method finishes auctions that expired by time.
+// Expired orders are found by the close time index instead of checking every order
+func (s *Storage) FinishExpiredAuctions(ctx context.Context, timestamp int64) error {
+	expired, err := s.getExpiredOrders(timestamp)
+	if err != nil {
+		return err
+	}
+
+	return s.FinishExpiredOrders(ctx, expired, timestamp)
+}
+
+// getExpiredOrders returns names of open orders expired by the given time in the close time order
+func (s *Storage) getExpiredOrders(timestamp int64) ([]string, error) {
+	var res []string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(bucketCloseIndex).Cursor()
+		for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+			closeTime, name := parseCloseIndexKey(key)
+			if closeTime >= timestamp {
+				break
+			}
+			res = append(res, name)
+		}
+		return nil
+	})
+
+	return res, err
+}
+
+// store saves orders to buckets
+type store struct {
+	db *bbolt.DB
+}
+
+// Load reads all orders with their auction history and ledger events
+func (st store) Load(_ context.Context) ([]inmemory.OrderState, []model.LedgerEvent, error) {
+	var (
+		states []inmemory.OrderState
+		events []model.LedgerEvent
+	)
+	err := st.db.View(func(tx *bbolt.Tx) error {
+		bids := tx.Bucket(bucketBids)
+		err := tx.Bucket(bucketOrders).ForEach(func(name, value []byte) error {
+			var state inmemory.OrderState
+			if err := json.Unmarshal(value, &state.Order); err != nil {
+				return err
+			}
+
+			if history := bids.Bucket(name); history != nil {
+				err := history.ForEach(func(_, value []byte) error {
+					var action model.OrderAction
+					if err := json.Unmarshal(value, &action); err != nil {
+						return err
+					}
+					state.History = append(state.History, action)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			states = append(states, state)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(bucketLedger).ForEach(func(_, value []byte) error {
+			var event model.LedgerEvent
+			if err := json.Unmarshal(value, &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return states, events, nil
+}
+
+// Save writes the changed orders, their new history actions and new ledger events in one transaction
+func (st store) Save(_ context.Context, changes []inmemory.OrderState, events []model.LedgerEvent) error {
+	return st.db.Update(func(tx *bbolt.Tx) error {
+		for _, state := range changes {
+			if err := saveOrder(tx, state.Order); err != nil {
+				return err
+			}
+			if err := saveHistory(tx, state.Order.Item.Name, state.History); err != nil {
+				return err
+			}
+		}
+		for _, event := range events {
+			if err := saveLedgerEvent(tx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// saveOrder puts the order and moves it in the close time index
+func saveOrder(tx *bbolt.Tx, order model.Order) error {
+	orders := tx.Bucket(bucketOrders)
+	closeIndex := tx.Bucket(bucketCloseIndex)
+	name := []byte(order.Item.Name)
+
+	if value := orders.Get(name); value != nil {
+		var saved model.Order
+		if err := json.Unmarshal(value, &saved); err != nil {
+			return err
+		}
+		if err := closeIndex.Delete(closeIndexKey(saved.CloseTime, saved.Item.Name)); err != nil {
+			return err
+		}
+	}
+	if order.Status == model.OrderStatusInit {
+		if err := closeIndex.Put(closeIndexKey(order.CloseTime, order.Item.Name), nil); err != nil {
+			return err
+		}
+	}
+
+	value, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	return orders.Put(name, value)
+}
+
+// saveHistory puts new actions of the auction history and actions of retracted and dropped bids.
+// The sequence of the bucket is the number of saved actions
+func saveHistory(tx *bbolt.Tx, itemName string, history []model.OrderAction) error {
+	bucket, err := tx.Bucket(bucketBids).CreateBucketIfNotExists([]byte(itemName))
+	if err != nil {
+		return err
+	}
+
+	saved := bucket.Sequence()
+	for seq, action := range history {
+		if uint64(seq) < saved && !action.Retracted && !action.Dropped {
+			continue
+		}
+
+		value, err := json.Marshal(action)
+		if err != nil {
+			return err
+		}
+		if err = bucket.Put(sequenceKey(uint64(seq)), value); err != nil {
+			return err
+		}
+	}
+
+	return bucket.SetSequence(uint64(len(history)))
+}
+
+// saveLedgerEvent puts the ledger event under the next sequence number of the bucket
+func saveLedgerEvent(tx *bbolt.Tx, event model.LedgerEvent) error {
+	bucket := tx.Bucket(bucketLedger)
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put(sequenceKey(seq), value)
+}
+
+// sequenceKey encodes the sequence number sorted in the byte order
+func sequenceKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
+
+// closeIndexKey encodes the close time sorted in the byte order followed by the order name
+func closeIndexKey(closeTime int64, name string) []byte {
+	key := binary.BigEndian.AppendUint64(nil, uint64(closeTime)^(1<<63))
+	return append(key, name...)
+}
+
+func parseCloseIndexKey(key []byte) (int64, string) {
+	closeTime := int64(binary.BigEndian.Uint64(key[:8]) ^ (1 << 63))
+	return closeTime, string(key[8:])
+}
@@ -0,0 +1,113 @@
+package bolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/auction"
+	"github.com/senseyman/auction-house/service/ledger"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func newTestStorage(t *testing.T, path string, opts ...inmemory.Option) *Storage {
+	s, err := New(context.TODO(), path, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		s.Close()
+	})
+
+	return s
+}
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) auction.Storage {
+		return newTestStorage(t, filepath.Join(t.TempDir(), "auction.db"))
+	})
+}
+
+func TestStorage_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auction.db")
+	orders := storagetest.GenerateOrders(3)
+
+	ledgerService := ledger.New()
+	s := newTestStorage(t, path, inmemory.WithRetractionWindow(5), inmemory.WithLedger(ledgerService))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 2200}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 5, ItemName: "phone_2", BidAmount: 2100}))
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 15, UserID: 3, ItemName: "phone_2"}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	expRes, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	expBalance := ledgerService.TrialBalance()
+	assert.NotEmpty(t, expBalance)
+	assert.NoError(t, s.Close())
+
+	// the state and the ledger are loaded back when the file is opened again
+	ledgerService = ledger.New()
+	s = newTestStorage(t, path, inmemory.WithRetractionWindow(5), inmemory.WithLedger(ledgerService))
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, expRes, results)
+	assert.Equal(t, expBalance, ledgerService.TrialBalance())
+
+	// rules apply to the loaded state: the retracted bid stays retracted, closed auctions are not closed again
+	err = s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 17, UserID: 3, ItemName: "phone_2"})
+	assert.ErrorIs(t, err, model.ErrBidNotFound)
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 17, UserID: 3, ItemName: "phone_1", BidAmount: 5000})
+	assert.ErrorIs(t, err, model.ErrAuctionIsClosed)
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	results, err = s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusSold, results[1].Status)
+	assert.Equal(t, 5, results[1].UserID)
+	assert.Equal(t, model.Money(2000), results[1].PricePaid)
+}
+
+func TestStorage_GetExpiredOrders(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+	orders[2].CloseTime = 12
+
+	s := newTestStorage(t, filepath.Join(t.TempDir(), "auction.db"))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+
+	expired, err := s.getExpiredOrders(21)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"phone_3", "phone_1", "phone_2"}, expired)
+
+	// extended and closed orders are moved in the index
+	assert.NoError(t, s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 11, UserID: 99, ItemName: "phone_3", CloseTime: 30}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+
+	expired, err = s.getExpiredOrders(100)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"phone_2", "phone_3"}, expired)
+
+	// the reopened order is put back, closed orders are removed
+	assert.NoError(t, s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 17, UserID: 99, ItemName: "phone_1", CloseTime: 40}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 31))
+
+	expired, err = s.getExpiredOrders(100)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"phone_1"}, expired)
+}
+
+func TestCloseIndexKey(t *testing.T) {
+	for _, closeTime := range []int64{-5, 0, 20} {
+		parsedTime, name := parseCloseIndexKey(closeIndexKey(closeTime, "phone_1"))
+		assert.Equal(t, closeTime, parsedTime)
+		assert.Equal(t, "phone_1", name)
+	}
+}
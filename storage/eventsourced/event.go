@@ -0,0 +1,85 @@
+package eventsourced
+
+import (
+	"context"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+type EventType string
+
+// list of domain events. Every event of the auction carries the state of its order after the event and actions
+// appended to the auction history, so read models are built by folding events without applying auction rules again
+const (
+	EventAuctionOpened    EventType = "AUCTION_OPENED"
+	EventBidAccepted      EventType = "BID_ACCEPTED"
+	EventBidRejected      EventType = "BID_REJECTED" // the order is not changed
+	EventBidRetracted     EventType = "BID_RETRACTED"
+	EventDepositPosted    EventType = "DEPOSIT_POSTED"
+	EventReserveLowered   EventType = "RESERVE_LOWERED"
+	EventAuctionCancelled EventType = "AUCTION_CANCELLED"
+	EventPaymentRecorded  EventType = "PAYMENT_RECORDED"
+	EventOrderRefunded    EventType = "ORDER_REFUNDED"
+	EventOrderSettled     EventType = "ORDER_SETTLED"
+	EventAuctionExtended  EventType = "AUCTION_EXTENDED"
+	EventAuctionVoided    EventType = "AUCTION_VOIDED"
+	EventAuctionReopened  EventType = "AUCTION_REOPENED"
+	EventAuctionClosed    EventType = "AUCTION_CLOSED"  // closed by time or by the admin
+	EventAuctionChanged   EventType = "AUCTION_CHANGED" // changed by the event of another auction or by time, like dropped bids and expired offers
+	EventLedgerPosted     EventType = "LEDGER_POSTED"   // money movement posted to the ledger
+)
+
+// Event is the fact that happened to the auction. Events are never changed once appended
+type Event struct {
+	Seq       int64 // position of the event in the stream, starting from 1
+	Type      EventType
+	Item      string
+	Timestamp int64
+
+	Order   *model.Order        // state of the order after the event
+	Actions []model.OrderAction // actions appended to the auction history by the event
+	Result  *model.ActionResult // result of the auction after the event
+
+	Bid      *model.BidCommand  // accepted or rejected bid
+	BidValue model.Money        // accepted bid in the currency of the auction
+	Reason   string             // reason the bid is rejected
+	Ledger   *model.LedgerEvent // money movement posted to the ledger
+}
+
+// EventStore is the append-only stream of events
+type EventStore interface {
+	// Load reads all events in the order they were appended
+	Load(ctx context.Context) ([]Event, error)
+	// Append writes events atomically: all of them or none
+	Append(ctx context.Context, events []Event) error
+}
+
+// MemoryStore keeps the stream of events in memory
+type MemoryStore struct {
+	mx sync.RWMutex
+
+	events []Event
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Load reads all events in the order they were appended
+func (st *MemoryStore) Load(_ context.Context) ([]Event, error) {
+	st.mx.RLock()
+	defer st.mx.RUnlock()
+
+	return append([]Event(nil), st.events...), nil
+}
+
+// Append writes events to the end of the stream
+func (st *MemoryStore) Append(_ context.Context, events []Event) error {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	st.events = append(st.events, events...)
+
+	return nil
+}
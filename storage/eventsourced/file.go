@@ -0,0 +1,112 @@
+package eventsourced
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// FileStore appends events to the file, one JSON encoded batch of appended events per line.
+// The batch torn by the crash is cut off on open, so events of one append are kept all or none
+type FileStore struct {
+	mx sync.Mutex
+
+	file *os.File
+	size int64
+}
+
+// NewFileStore opens the event file, creating it if needed
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &FileStore{file: file}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if st.size, err = st.scan(info.Size(), func([]Event) {}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err = file.Truncate(st.size); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// Load reads all events in the order they were appended
+func (st *FileStore) Load(_ context.Context) ([]Event, error) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	var res []Event
+	_, err := st.scan(st.size, func(events []Event) {
+		res = append(res, events...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Append writes events as one line and flushes it to the disk
+func (st *FileStore) Append(_ context.Context, events []Event) error {
+	line, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	// the line partially written on failure is overwritten by the next append
+	if _, err = st.file.WriteAt(line, st.size); err != nil {
+		return err
+	}
+	if err = st.file.Sync(); err != nil {
+		return err
+	}
+	st.size += int64(len(line))
+
+	return nil
+}
+
+// Close closes the event file
+func (st *FileStore) Close() error {
+	return st.file.Close()
+}
+
+// scan reads complete batches of the first size bytes of the file and returns the size they take
+func (st *FileStore) scan(size int64, fn func(events []Event)) (int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(st.file, 0, size))
+	var valid int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// the incomplete line is torn by the crash
+			return valid, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var events []Event
+		if err = json.Unmarshal(line, &events); err != nil {
+			return valid, nil
+		}
+		fn(events)
+		valid += int64(len(line))
+	}
+}
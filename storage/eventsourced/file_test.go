@@ -0,0 +1,60 @@
+package eventsourced
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestNewFileStore(t *testing.T) {
+	st, err := NewFileStore(filepath.Join(t.TempDir(), "events.jsonl"))
+	assert.NoError(t, err)
+	assert.NoError(t, st.Close())
+
+	_, err = NewFileStore(filepath.Join(t.TempDir(), "missing", "events.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestFileStore_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	order := storagetest.GenerateOrders(1)[0]
+	bid := model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}
+	events := []Event{
+		{Seq: 1, Type: EventAuctionOpened, Item: "phone_1", Timestamp: 10, Order: &order},
+		{Seq: 2, Type: EventBidAccepted, Item: "phone_1", Timestamp: 12, Bid: &bid, BidValue: 2500},
+	}
+
+	st, err := NewFileStore(path)
+	require.NoError(t, err)
+	assert.NoError(t, st.Append(context.TODO(), events[:1]))
+	assert.NoError(t, st.Append(context.TODO(), events[1:]))
+	loaded, err := st.Load(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, events, loaded)
+	assert.NoError(t, st.Close())
+
+	// events are read back after reopening, the batch torn by the crash is cut off
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(data, `[{"Seq":3,`...), 0o600))
+
+	st, err = NewFileStore(path)
+	require.NoError(t, err)
+	loaded, err = st.Load(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, events, loaded)
+
+	event := Event{Seq: 3, Type: EventAuctionChanged, Item: "phone_1"}
+	assert.NoError(t, st.Append(context.TODO(), []Event{event}))
+	loaded, err = st.Load(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, append(events, event), loaded)
+	assert.NoError(t, st.Close())
+}
@@ -0,0 +1,192 @@
+package eventsourced
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/inmemory"
+)
+
+// Projection is the read model built from the stream of events
+type Projection interface {
+	// Apply updates the projection with the next event of the stream
+	Apply(event Event)
+	// Reset clears the projection before the stream is replayed
+	Reset()
+}
+
+// Orders projects events to orders with their auction history. It's safe to read while events are applied
+type Orders struct {
+	mx sync.RWMutex
+
+	states map[string]inmemory.OrderState // key - order name
+}
+
+func NewOrders() *Orders {
+	p := &Orders{}
+	p.Reset()
+
+	return p
+}
+
+// Apply replaces the order by its state after the event and appends actions of the event to its auction history
+func (p *Orders) Apply(event Event) {
+	if event.Order == nil {
+		return
+	}
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	state := p.states[event.Item]
+	state.Order = *event.Order
+	for _, action := range event.Actions {
+		state.History = inmemory.AppendAction(state.History, action)
+	}
+	if event.Result != nil {
+		state.Result = *event.Result
+	}
+	p.states[event.Item] = state
+}
+
+// Reset removes all orders
+func (p *Orders) Reset() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.states = make(map[string]inmemory.OrderState)
+}
+
+// Get returns the order with its auction history
+func (p *Orders) Get(item string) (inmemory.OrderState, bool) {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+
+	state, ok := p.states[item]
+
+	return state, ok
+}
+
+// List returns all orders with their auction history sorted by order name
+func (p *Orders) List() []inmemory.OrderState {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+
+	res := make([]inmemory.OrderState, 0, len(p.states))
+	for _, state := range p.states {
+		res = append(res, state)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Order.Item.Name < res[j].Order.Item.Name
+	})
+
+	return res
+}
+
+// Results projects events to auction results. It's safe to read while events are applied
+type Results struct {
+	mx sync.RWMutex
+
+	results map[string]model.ActionResult // key - order name
+}
+
+func NewResults() *Results {
+	p := &Results{}
+	p.Reset()
+
+	return p
+}
+
+// Apply keeps the result of the auction after the event
+func (p *Results) Apply(event Event) {
+	if event.Result == nil {
+		return
+	}
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.results[event.Item] = *event.Result
+}
+
+// Reset removes all results
+func (p *Results) Reset() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.results = make(map[string]model.ActionResult)
+}
+
+// List returns results of all auctions in the time order they were created
+func (p *Results) List() []model.ActionResult {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+
+	res := make([]model.ActionResult, 0, len(p.results))
+	for _, result := range p.results {
+		res = append(res, result)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].CreationTime != res[j].CreationTime {
+			return res[i].CreationTime < res[j].CreationTime
+		}
+		return res[i].Item < res[j].Item
+	})
+
+	return res
+}
+
+// ItemStatistics provides statistics of bids on the item
+type ItemStatistics struct {
+	model.AuctionStatistics
+	RejectedBidCount int
+}
+
+// Statistics projects bid events to statistics of items. It's safe to read while events are applied
+type Statistics struct {
+	mx sync.RWMutex
+
+	history  map[string][]model.OrderAction // auction history of items
+	rejected map[string]int                 // number of rejected bids
+}
+
+func NewStatistics() *Statistics {
+	p := &Statistics{}
+	p.Reset()
+
+	return p
+}
+
+// Apply counts bids of the event
+func (p *Statistics) Apply(event Event) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	for _, action := range event.Actions {
+		p.history[event.Item] = inmemory.AppendAction(p.history[event.Item], action)
+	}
+	if event.Type == EventBidRejected {
+		p.rejected[event.Item]++
+	}
+}
+
+// Reset removes statistics of all items
+func (p *Statistics) Reset() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.history = make(map[string][]model.OrderAction)
+	p.rejected = make(map[string]int)
+}
+
+// Get returns statistics of the item counted the same way as in auction results
+func (p *Statistics) Get(item string) ItemStatistics {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+
+	return ItemStatistics{
+		AuctionStatistics: inmemory.CountBids(p.history[item]),
+		RejectedBidCount:  p.rejected[item],
+	}
+}
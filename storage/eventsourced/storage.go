@@ -0,0 +1,403 @@
+// Package eventsourced provides the auction storage whose authoritative state is the stream of domain events.
+// Every accepted command is appended as events of the auctions it changed: the order after the change, actions
+// appended to its auction history and its result, followed by money movements posted to the ledger. The rejected
+// bid is appended as the event too. Orders and auction results are read models folded from events without applying
+// auction rules again, so they are rebuilt the same with any auction policies, and projections like bid statistics
+// can be rebuilt or added later by replaying the stream.
+package eventsourced
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/inmemory"
+)
+
+// Storage appends events of every call to the store and applies them to projections.
+// Auction rules of new commands are applied by the in-memory storage restored from the orders read model
+type Storage struct {
+	mx sync.Mutex
+
+	store       EventStore
+	engine      *inmemory.Storage
+	orders      *Orders
+	results     *Results
+	projections []Projection // projections added by users
+	lastSeq     int64
+}
+
+// New folds the stored events to orders and results and posts stored ledger events to the ledger.
+// Options configure auction policies of new commands the same way as for the in-memory storage
+func New(ctx context.Context, store EventStore, opts ...inmemory.Option) (*Storage, error) {
+	s := &Storage{
+		store:   store,
+		engine:  inmemory.New(append(opts, inmemory.WithChangeTracking())...),
+		orders:  NewOrders(),
+		results: NewResults(),
+	}
+
+	events, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	s.project(events)
+	if err = s.engine.Restore(s.orders.List()); err != nil {
+		return nil, fmt.Errorf("failed to restore auctions: %w", err)
+	}
+	if err = s.engine.PostLedgerEvents(ledgerEvents(events)); err != nil {
+		return nil, fmt.Errorf("failed to post ledger events: %w", err)
+	}
+
+	return s, nil
+}
+
+// AddProjection builds the projection from all stored events and keeps it updated with new ones
+func (s *Storage) AddProjection(ctx context.Context, projection Projection) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	events, err := s.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	projection.Reset()
+	for _, event := range events {
+		projection.Apply(event)
+	}
+	s.projections = append(s.projections, projection)
+
+	return nil
+}
+
+// Rebuild replays stored events to all projections, orders and results included, and restores auctions from them.
+// The ledger keeps events posted before
+func (s *Storage) Rebuild(ctx context.Context) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	events, err := s.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.orders.Reset()
+	s.results.Reset()
+	for _, projection := range s.projections {
+		projection.Reset()
+	}
+	s.project(events)
+
+	return s.engine.Restore(s.orders.List())
+}
+
+// CreateOrder method stores order and initiate first auction state
+func (s *Storage) CreateOrder(ctx context.Context, order model.Order) error {
+	return s.record(ctx, EventAuctionOpened, order.Item.Name, func() error {
+		return s.engine.CreateOrder(ctx, order)
+	})
+}
+
+// BidOrder method checks bid value for the order and saves the bid to the history data.
+// The rejected bid is saved as the event too
+func (s *Storage) BidOrder(ctx context.Context, bid model.BidCommand) error {
+	return s.recordBid(ctx, bid)
+}
+
+// PostDeposit method saves the deposit of the bidder to the opened order
+func (s *Storage) PostDeposit(ctx context.Context, deposit model.DepositCommand) error {
+	return s.record(ctx, EventDepositPosted, deposit.ItemName, func() error {
+		return s.engine.PostDeposit(ctx, deposit)
+	})
+}
+
+// CancelOrder method withdraws the order by its seller while the reserve price is not met
+func (s *Storage) CancelOrder(ctx context.Context, cancel model.CancelCommand) error {
+	return s.record(ctx, EventAuctionCancelled, cancel.ItemName, func() error {
+		return s.engine.CancelOrder(ctx, cancel)
+	})
+}
+
+// RetractBid method withdraws the latest bid of the user
+func (s *Storage) RetractBid(ctx context.Context, retract model.RetractCommand) error {
+	return s.record(ctx, EventBidRetracted, retract.ItemName, func() error {
+		return s.engine.RetractBid(ctx, retract)
+	})
+}
+
+// LowerReserve method lowers the reserve price of the opened order by its seller
+func (s *Storage) LowerReserve(ctx context.Context, reserve model.ReserveCommand) error {
+	return s.record(ctx, EventReserveLowered, reserve.ItemName, func() error {
+		return s.engine.LowerReserve(ctx, reserve)
+	})
+}
+
+// RecordPayment method saves the payment outcome of the current buyer of the sold order
+func (s *Storage) RecordPayment(ctx context.Context, payment model.PaymentCommand) error {
+	return s.record(ctx, EventPaymentRecorded, payment.ItemName, func() error {
+		return s.engine.RecordPayment(ctx, payment)
+	})
+}
+
+// RefundOrder method returns the payment to the buyer of the paid order
+func (s *Storage) RefundOrder(ctx context.Context, cmd model.AdminCommand) error {
+	return s.record(ctx, EventOrderRefunded, cmd.ItemName, func() error {
+		return s.engine.RefundOrder(ctx, cmd)
+	})
+}
+
+// SettleOrder method pays out the paid order to the seller
+func (s *Storage) SettleOrder(ctx context.Context, cmd model.AdminCommand) error {
+	return s.record(ctx, EventOrderSettled, cmd.ItemName, func() error {
+		return s.engine.SettleOrder(ctx, cmd)
+	})
+}
+
+// ExtendAuction method moves the close time of the opened order to the later time
+func (s *Storage) ExtendAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.record(ctx, EventAuctionExtended, cmd.ItemName, func() error {
+		return s.engine.ExtendAuction(ctx, cmd)
+	})
+}
+
+// ForceCloseAuction method closes the opened order before its close time
+func (s *Storage) ForceCloseAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.record(ctx, EventAuctionClosed, cmd.ItemName, func() error {
+		return s.engine.ForceCloseAuction(ctx, cmd)
+	})
+}
+
+// VoidAuction method invalidates the order
+func (s *Storage) VoidAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.record(ctx, EventAuctionVoided, cmd.ItemName, func() error {
+		return s.engine.VoidAuction(ctx, cmd)
+	})
+}
+
+// ReopenAuction method opens the closed order again until the new close time
+func (s *Storage) ReopenAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.record(ctx, EventAuctionReopened, cmd.ItemName, func() error {
+		return s.engine.ReopenAuction(ctx, cmd)
+	})
+}
+
+// FinishExpiredAuctions method finishes auctions that expired by time
+func (s *Storage) FinishExpiredAuctions(ctx context.Context, timestamp int64) error {
+	return s.record(ctx, EventAuctionChanged, "", func() error {
+		return s.engine.FinishExpiredAuctions(ctx, timestamp)
+	})
+}
+
+// FinishAllAuctions method finishes unfinished auctions
+func (s *Storage) FinishAllAuctions(ctx context.Context) error {
+	return s.record(ctx, EventAuctionChanged, "", func() error {
+		return s.engine.FinishAllAuctions(ctx)
+	})
+}
+
+// GetAuctionResults provides results of all auctions folded from events
+func (s *Storage) GetAuctionResults(_ context.Context) ([]model.ActionResult, error) {
+	return s.results.List(), nil
+}
+
+// GetOrder provides the order with its current leader and price
+func (s *Storage) GetOrder(ctx context.Context, itemName string) (model.AuctionView, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.GetOrder(ctx, itemName)
+}
+
+// ListOpenAuctions provides open orders matching the filter in the close order
+func (s *Storage) ListOpenAuctions(ctx context.Context, filter model.AuctionFilter) ([]model.AuctionView, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.ListOpenAuctions(ctx, filter)
+}
+
+// GetBidHistory provides the page of bids on the order in the order they were placed
+func (s *Storage) GetBidHistory(ctx context.Context, itemName string, page model.Page) (model.BidHistory, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.GetBidHistory(ctx, itemName, page)
+}
+
+// GetOrderAt provides the state of the order as of the time
+func (s *Storage) GetOrderAt(ctx context.Context, itemName string, timestamp int64) (model.AuctionState, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.GetOrderAt(ctx, itemName, timestamp)
+}
+
+// record runs the command and appends events of orders it changed followed by ledger events it posted.
+// Changes of the command are dropped if it fails halfway or its events can't be appended
+func (s *Storage) record(ctx context.Context, eventType EventType, itemName string, command func() error) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	events, err := s.run(eventType, itemName, command)
+	if err != nil {
+		return err
+	}
+
+	return s.append(ctx, events)
+}
+
+// recordBid runs the bid command. The rejected bid is appended as the event, the accepted one carries
+// the bid and its value in the currency of the auction
+func (s *Storage) recordBid(ctx context.Context, bid model.BidCommand) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	events, opErr := s.run(EventBidAccepted, bid.ItemName, func() error {
+		return s.engine.BidOrder(ctx, bid)
+	})
+	if opErr != nil {
+		events = []Event{{
+			Type:      EventBidRejected,
+			Item:      bid.ItemName,
+			Timestamp: bid.Timestamp,
+			Bid:       &bid,
+			Reason:    opErr.Error(),
+		}}
+	}
+	for idx := range events {
+		if events[idx].Type == EventBidAccepted {
+			events[idx].Bid = &bid
+			events[idx].BidValue = lastBidValue(events[idx].Actions)
+		}
+	}
+
+	if err := s.append(ctx, events); err != nil {
+		return err
+	}
+
+	return opErr
+}
+
+// run runs the command and returns events of orders it changed followed by ledger events it posted.
+// The changed order of the command is the event of the given type unless the command closed it
+func (s *Storage) run(eventType EventType, itemName string, command func() error) ([]Event, error) {
+	opErr := command()
+	changes := s.engine.ChangedOrders()
+	posted := s.engine.ChangedLedgerEvents()
+	if opErr != nil {
+		if len(changes) > 0 || len(posted) > 0 {
+			// changes of the failed command are dropped
+			if err := s.engine.Restore(s.orders.List()); err != nil {
+				return nil, fmt.Errorf("%w, failed to restore auctions: %v", opErr, err)
+			}
+		}
+		return nil, opErr
+	}
+
+	events := make([]Event, 0, len(changes)+len(posted))
+	for _, change := range changes {
+		events = append(events, s.changeEvent(eventType, itemName, change))
+	}
+	// orders closed by time are in the close time order
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+	for idx := range posted {
+		events = append(events, Event{
+			Type:      EventLedgerPosted,
+			Item:      posted[idx].Item,
+			Timestamp: posted[idx].Timestamp,
+			Ledger:    &posted[idx],
+		})
+	}
+
+	return events, nil
+}
+
+// changeEvent returns the event of the changed order with actions appended since the last event of the order
+func (s *Storage) changeEvent(eventType EventType, itemName string, change inmemory.OrderState) Event {
+	saved, known := s.orders.Get(change.Order.Item.Name)
+	order := change.Order
+	result := change.Result
+	event := Event{
+		Type:      eventType,
+		Item:      order.Item.Name,
+		Timestamp: order.CloseTime,
+		Order:     &order,
+		Actions:   change.History[len(saved.History):],
+		Result:    &result,
+	}
+	if len(event.Actions) > 0 {
+		event.Timestamp = event.Actions[len(event.Actions)-1].Timestamp
+	}
+
+	closed := order.Status == model.OrderStatusSold || order.Status == model.OrderStatusUnsold
+	switch {
+	case closed && (!known || saved.Order.Status == model.OrderStatusInit):
+		event.Type = EventAuctionClosed
+		event.Timestamp = order.CloseTime
+	case order.Item.Name != itemName:
+		event.Type = EventAuctionChanged
+	}
+
+	return event
+}
+
+// append appends events to the store and applies them to projections. If events can't be appended,
+// auctions are restored from the orders read model
+func (s *Storage) append(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	for idx := range events {
+		events[idx].Seq = s.lastSeq + int64(idx) + 1
+	}
+
+	if err := s.store.Append(ctx, events); err != nil {
+		if restoreErr := s.engine.Restore(s.orders.List()); restoreErr != nil {
+			return fmt.Errorf("failed to append events: %w, failed to restore auctions: %v", err, restoreErr)
+		}
+		return fmt.Errorf("failed to append events: %w", err)
+	}
+	s.project(events)
+
+	return s.engine.PostLedgerEvents(ledgerEvents(events))
+}
+
+// project applies events to orders, results and all projections
+func (s *Storage) project(events []Event) {
+	for _, event := range events {
+		s.orders.Apply(event)
+		s.results.Apply(event)
+		for _, projection := range s.projections {
+			projection.Apply(event)
+		}
+		s.lastSeq = event.Seq
+	}
+}
+
+// ledgerEvents returns money movements posted to the ledger by events
+func ledgerEvents(events []Event) []model.LedgerEvent {
+	var res []model.LedgerEvent
+	for _, event := range events {
+		if event.Type == EventLedgerPosted {
+			res = append(res, *event.Ledger)
+		}
+	}
+
+	return res
+}
+
+// lastBidValue returns the value of the latest bid of actions in the currency of the order
+func lastBidValue(actions []model.OrderAction) model.Money {
+	for idx := len(actions) - 1; idx >= 0; idx-- {
+		if actions[idx].Type == model.OrderActionTypeBid {
+			return actions[idx].BidValue
+		}
+	}
+
+	return 0
+}
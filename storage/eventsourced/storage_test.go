@@ -0,0 +1,258 @@
+package eventsourced
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/auction"
+	"github.com/senseyman/auction-house/service/ledger"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+var (
+	errStore     = errors.New("store is not available")
+	errConverter = errors.New("exchange rates are not available")
+)
+
+// brokenStore fails appending events when broken
+type brokenStore struct {
+	*MemoryStore
+	broken bool
+}
+
+func (st *brokenStore) Append(ctx context.Context, events []Event) error {
+	if st.broken {
+		return errStore
+	}
+
+	return st.MemoryStore.Append(ctx, events)
+}
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) auction.Storage {
+		s, err := New(context.TODO(), NewMemoryStore())
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestStorage_Events(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+	store := NewMemoryStore()
+
+	s, err := New(context.TODO(), store)
+	require.NoError(t, err)
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[1]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.Error(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "tv_1", BidAmount: 2500}))
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 13, UserID: 3, ItemName: "phone_1"}))
+	// the heartbeat closing nothing is not an event
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 14))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	events, err := store.Load(context.TODO())
+	require.NoError(t, err)
+	types := make([]EventType, 0, len(events))
+	for idx, event := range events {
+		assert.Equal(t, int64(idx+1), event.Seq)
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []EventType{
+		EventAuctionOpened,
+		EventAuctionOpened,
+		EventBidAccepted,
+		EventBidRejected,
+		EventBidRetracted,
+		EventAuctionClosed,
+		EventAuctionClosed,
+	}, types)
+
+	// events carry the order after the event and actions appended to its auction history
+	assert.Equal(t, model.OrderStatusInit, events[0].Order.Status)
+	assert.Equal(t, model.OrderActionTypeInit, events[0].Actions[0].Type)
+	assert.Equal(t, 3, events[2].Bid.UserID)
+	assert.Equal(t, model.Money(2500), events[2].BidValue)
+	assert.Equal(t, model.Money(2500), events[2].Order.LastBid)
+	assert.Equal(t, "tv_1", events[3].Item)
+	assert.Equal(t, model.ErrNotFound.Error(), events[3].Reason)
+	assert.Nil(t, events[3].Order)
+	assert.Equal(t, model.OrderActionTypeRetract, events[4].Actions[0].Type)
+	assert.Equal(t, model.Money(0), events[4].Order.LastBid)
+	assert.Equal(t, "phone_1", events[5].Item)
+	assert.Equal(t, model.OrderStatusUnsold, events[5].Result.Status)
+	assert.Equal(t, model.AuctionStatistics{}, events[5].Result.Statistics)
+}
+
+func TestStorage_Rebuild(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	orders := storagetest.GenerateOrders(2)
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	ledgerService := ledger.New()
+	s, err := New(context.TODO(), store, inmemory.WithLedger(ledgerService))
+	require.NoError(t, err)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 14, UserID: 4, ItemName: "phone_1"}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	expRes, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	expBalance := ledgerService.TrialBalance()
+	assert.NotEmpty(t, expBalance)
+	assert.NoError(t, store.Close())
+
+	// auctions and the ledger are rebuilt from events of the file
+	store, err = NewFileStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	ledgerService = ledger.New()
+	restored, err := New(context.TODO(), store, inmemory.WithLedger(ledgerService))
+	require.NoError(t, err)
+	results, err := restored.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, expRes, results)
+	assert.Equal(t, s.engine.Snapshot(), restored.engine.Snapshot())
+	assert.Equal(t, model.OrderStatusSold, results[0].Status)
+	assert.Equal(t, 3, results[0].UserID)
+	assert.Equal(t, expBalance, ledgerService.TrialBalance())
+
+	// the projection added later is built from past events
+	statistics := NewStatistics()
+	assert.NoError(t, restored.AddProjection(context.TODO(), statistics))
+	assert.ErrorIs(t, restored.BidOrder(context.TODO(), model.BidCommand{Timestamp: 17, UserID: 4, ItemName: "phone_1", BidAmount: 3500}), model.ErrAuctionIsClosed)
+	assert.NoError(t, restored.BidOrder(context.TODO(), model.BidCommand{Timestamp: 17, UserID: 4, ItemName: "phone_2", BidAmount: 3500}))
+	assert.Equal(t, ItemStatistics{
+		AuctionStatistics: model.AuctionStatistics{TotalBidCount: 1, HighestBid: 2500, LowestBid: 2500},
+		RejectedBidCount:  1,
+	}, statistics.Get("phone_1"))
+	assert.Equal(t, results[0].Statistics, statistics.Get("phone_1").AuctionStatistics)
+	assert.Equal(t, 1, statistics.Get("phone_2").TotalBidCount)
+
+	// rebuilding gives the same projections and doesn't post sales to the ledger again
+	assert.NoError(t, restored.Rebuild(context.TODO()))
+	assert.Equal(t, 1, statistics.Get("phone_1").RejectedBidCount)
+	assert.Equal(t, model.Money(3500), statistics.Get("phone_2").HighestBid)
+	assert.Equal(t, expBalance, ledgerService.TrialBalance())
+	assert.NoError(t, restored.FinishAllAuctions(context.TODO()))
+	results, err = restored.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, results[1].UserID)
+}
+
+func TestStorage_RestartWithOptions(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+	store := NewMemoryStore()
+
+	// user 3 wins two items while the number of wins is not limited
+	ledgerService := ledger.New()
+	s, err := New(context.TODO(), store, inmemory.WithLedger(ledgerService))
+	require.NoError(t, err)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_2", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "phone_3", BidAmount: 2500}))
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 14, UserID: 4, ItemName: "phone_3"}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 21))
+	expRes, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	expBalance := ledgerService.TrialBalance()
+
+	// the restart with stricter policies keeps what happened, policies apply to new commands only
+	ledgerService = ledger.New()
+	restored, err := New(context.TODO(), store, inmemory.WithLedger(ledgerService), inmemory.WithMaxWinsPerBidder(1), inmemory.WithRetractionWindow(1))
+	require.NoError(t, err)
+	results, err := restored.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, expRes, results)
+	assert.Equal(t, 3, results[0].UserID)
+	assert.Equal(t, 3, results[1].UserID)
+	assert.Equal(t, s.engine.Snapshot(), restored.engine.Snapshot())
+	assert.Equal(t, expBalance, ledgerService.TrialBalance())
+
+	err = restored.BidOrder(context.TODO(), model.BidCommand{Timestamp: 22, UserID: 3, ItemName: "phone_3", BidAmount: 3000})
+	assert.ErrorIs(t, err, model.ErrMaxWinsReached)
+}
+
+func TestStorage_AppendError(t *testing.T) {
+	orders := storagetest.GenerateOrders(1)
+	store := &brokenStore{MemoryStore: NewMemoryStore()}
+
+	s, err := New(context.TODO(), store)
+	require.NoError(t, err)
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+
+	// the bid not appended is rolled back
+	store.broken = true
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 3000})
+	assert.ErrorIs(t, err, errStore)
+
+	store.broken = false
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results[0].UserID)
+	assert.Equal(t, 1, results[0].Statistics.TotalBidCount)
+
+	events, err := store.Load(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+}
+
+// flakyConverter converts money by fixed rates to the reporting currency and fails the given number of next conversions
+type flakyConverter struct {
+	rates    map[model.Currency]int64
+	failures int
+}
+
+func (c *flakyConverter) Convert(amount model.Money, from, to model.Currency, _ int64) (model.Money, error) {
+	if c.failures > 0 {
+		c.failures--
+		return 0, errConverter
+	}
+
+	return amount.MulDiv(c.rates[from], c.rates[to]), nil
+}
+
+func TestStorage_OperationError(t *testing.T) {
+	order := storagetest.GenerateOrders(1)[0]
+	order.Item.Currency = "EUR"
+	converter := &flakyConverter{rates: map[model.Currency]int64{"": 100, "EUR": 110}}
+	store := NewMemoryStore()
+	ledgerService := ledger.New()
+
+	s, err := New(context.TODO(), store, inmemory.WithCurrencyConverter(converter), inmemory.WithLedger(ledgerService))
+	require.NoError(t, err)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500, Currency: "EUR"}))
+	assert.NoError(t, s.ForceCloseAuction(context.TODO(), model.AdminCommand{Timestamp: 13, UserID: 99, ItemName: "phone_1"}))
+	assert.Len(t, ledgerService.Entries(), 1)
+
+	// the reopened auction fails to count the exposure of the leader after the sale is reversed,
+	// nothing of the failed command is kept or appended
+	converter.failures = 1
+	err = s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 14, UserID: 99, ItemName: "phone_1", CloseTime: 30})
+	assert.ErrorIs(t, err, errConverter)
+
+	view, err := s.GetOrder(context.TODO(), "phone_1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusSold, view.Order.Status)
+	events, err := store.Load(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+	assert.Len(t, ledgerService.Entries(), 1)
+}
@@ -0,0 +1,132 @@
+package inmemory
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// Archive is the cold store of closed orders moved out of memory
+type Archive interface {
+	Put(ctx context.Context, state OrderState) error
+	Get(ctx context.Context, orderName string) (OrderState, error)
+	Results(ctx context.Context) ([]model.ActionResult, error)
+}
+
+// WithArchive moves closed orders to the archive the given number of seconds after the heartbeat they are found closed at.
+// Only results of archived orders are kept in memory, an archived order is loaded back when a command refers to it
+func WithArchive(archive Archive, after int64) Option {
+	return func(s *Storage) {
+		s.archive = archive
+		s.archiveAfter = after
+	}
+}
+
+// isArchivable checks the order is closed and doesn't wait for the payment
+func isArchivable(order *model.Order) bool {
+	return order.Status != model.OrderStatusInit && order.SettlementStatus != model.SettlementStatusAwaitingPayment
+}
+
+// archiveOrders moves orders closed for the archiving period to the archive. Orders changed since the last
+// ChangedOrders call are left till the next heartbeat to be saved by persistent storages first. All shards must be locked
+func (s *Storage) archiveOrders(ctx context.Context, timestamp int64) error {
+	if s.archive == nil {
+		return nil
+	}
+
+	// schedule orders closed since the last heartbeat
+	for _, sh := range s.shards {
+		for orderName := range sh.touched {
+			order, ok := sh.orders[orderName]
+			if !ok || !isArchivable(order) {
+				delete(sh.archiveAt, orderName)
+				continue
+			}
+			s.scheduleArchive(sh, order, timestamp+s.archiveAfter)
+		}
+		clear(sh.touched)
+	}
+
+	for {
+		next, ok := s.popDue(func(sh *shard) *deadlineQueue { return &sh.archiveQueue }, func(time int64) bool {
+			return time <= timestamp
+		})
+		if !ok {
+			return nil
+		}
+
+		sh := s.shardOf(next.name)
+		order, ok := sh.orders[next.name]
+		if !ok || sh.archiveAt[next.name] != next.time {
+			// the order is archived or changed after the deadline is scheduled
+			continue
+		}
+		if _, ok = sh.changed[next.name]; ok {
+			s.scheduleArchive(sh, order, timestamp+1)
+			continue
+		}
+
+		state := s.getOrderState(order)
+		if err := s.archive.Put(ctx, state); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", next.name, err)
+		}
+		sh.summaries[next.name] = state.Result
+		delete(sh.orders, next.name)
+		delete(sh.auctionHistory, next.name)
+		delete(sh.statistics, next.name)
+		delete(sh.archiveAt, next.name)
+	}
+}
+
+// scheduleArchive adds the archiving time of the closed order to the archive queue of its shard
+func (s *Storage) scheduleArchive(sh *shard, order *model.Order, time int64) {
+	sh.archiveAt[order.Item.Name] = time
+	heap.Push(&sh.archiveQueue, deadline{
+		time:         time,
+		creationTime: order.CreationTime,
+		name:         order.Item.Name,
+	})
+}
+
+// loadOrder returns the order, the archived order is loaded back from the archive. The shard of the order must be locked
+func (s *Storage) loadOrder(ctx context.Context, sh *shard, orderName string) (*model.Order, error) {
+	if order, ok := sh.orders[orderName]; ok {
+		return order, nil
+	}
+	if _, ok := sh.summaries[orderName]; !ok {
+		return nil, model.ErrNotFound
+	}
+
+	state, err := s.archive.Get(ctx, orderName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s from archive: %w", orderName, err)
+	}
+	// the order is archived again on the next heartbeat if it's not changed
+	order := s.putState(sh, state)
+	delete(sh.summaries, orderName)
+
+	return order, nil
+}
+
+// restoreSummaries keeps results and counts wins of archived orders which are not restored. All shards must be locked
+func (s *Storage) restoreSummaries(ctx context.Context) error {
+	if s.archive == nil {
+		return nil
+	}
+
+	results, err := s.archive.Results(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load archived results: %w", err)
+	}
+	for _, result := range results {
+		sh := s.shardOf(result.Item)
+		if _, ok := sh.orders[result.Item]; !ok {
+			sh.summaries[result.Item] = result
+			s.countWin(0, result.UserID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+// testArchive keeps archived states by order name
+type testArchive map[string]OrderState
+
+func (a testArchive) Put(_ context.Context, state OrderState) error {
+	a[state.Order.Item.Name] = state
+	return nil
+}
+
+func (a testArchive) Get(_ context.Context, orderName string) (OrderState, error) {
+	state, ok := a[orderName]
+	if !ok {
+		return OrderState{}, model.ErrNotFound
+	}
+	return state, nil
+}
+
+func (a testArchive) Results(_ context.Context) ([]model.ActionResult, error) {
+	results := make([]model.ActionResult, 0, len(a))
+	for _, state := range a {
+		results = append(results, state.Result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Item < results[j].Item
+	})
+	return results, nil
+}
+
+func TestStorage_Archive(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+	orders[2].CloseTime = 100
+	archive := testArchive{}
+
+	s := New(WithArchive(archive, 10), WithMaxWinsPerBidder(1))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "phone_2", BidAmount: 1000}))
+	expResults, err := s.GetAuctionResults(context.TODO())
+	require.NoError(t, err)
+
+	// phone_1 is sold and waits for the payment, phone_2 is unsold
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 21))
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 22, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusPaid}))
+
+	// orders are archived the archiving period after the heartbeat they are found closed at, open orders stay in memory
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 23))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 30))
+	assert.Empty(t, archive)
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 31))
+	assert.Len(t, archive, 1)
+	assert.Nil(t, s.getOrder("phone_2"))
+	assert.Nil(t, s.getHistory("phone_2"))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 33))
+	assert.Len(t, archive, 2)
+	assert.Nil(t, s.getOrder("phone_1"))
+	assert.NotNil(t, s.getOrder("phone_3"))
+
+	// results and statistics of archived orders are kept
+	results, err := s.GetAuctionResults(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, model.SettlementStatusPaid, results[0].SettlementStatus)
+	assert.Equal(t, expResults[0].Statistics, results[0].Statistics)
+	assert.Equal(t, model.OrderStatusUnsold, results[1].Status)
+	statistics, err := s.GetAuctionStatistics(context.TODO(), "phone_1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, statistics.TotalBidCount)
+
+	// the winner of the archived order can't win more
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 34, UserID: 3, ItemName: "phone_3", BidAmount: 2500})
+	assert.ErrorIs(t, err, model.ErrMaxWinsReached)
+
+	// commands load the archived order back, it's archived again when the period passes
+	assert.NoError(t, s.SettleOrder(context.TODO(), model.AdminCommand{Timestamp: 34, ItemName: "phone_1"}))
+	assert.Equal(t, model.SettlementStatusSettled, s.getOrder("phone_1").SettlementStatus)
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 35))
+	assert.NotNil(t, s.getOrder("phone_1"))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 45))
+	assert.Nil(t, s.getOrder("phone_1"))
+	assert.Equal(t, model.SettlementStatusSettled, archive["phone_1"].Order.SettlementStatus)
+
+	// the restored storage keeps results of archived orders
+	restored := New(WithArchive(archive, 10), WithMaxWinsPerBidder(1))
+	assert.NoError(t, restored.Restore(s.Snapshot()))
+	restoredResults, err := restored.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	results, err = s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, results, restoredResults)
+	err = restored.BidOrder(context.TODO(), model.BidCommand{Timestamp: 46, UserID: 3, ItemName: "phone_3", BidAmount: 2500})
+	assert.ErrorIs(t, err, model.ErrMaxWinsReached)
+}
+
+func TestStorage_ArchiveChangedOrders(t *testing.T) {
+	orders := storagetest.GenerateOrders(1)
+	archive := testArchive{}
+
+	s := New(WithArchive(archive, 0), WithChangeTracking())
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	s.ChangedOrders()
+
+	// the closed order is archived after its change is taken
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	assert.Empty(t, archive)
+	changes := s.ChangedOrders()
+	require.Len(t, changes, 1)
+	assert.Equal(t, model.OrderStatusUnsold, changes[0].Order.Status)
+
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 17))
+	assert.Len(t, archive, 1)
+	assert.Empty(t, s.ChangedOrders())
+}
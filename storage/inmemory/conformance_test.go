@@ -0,0 +1,15 @@
+package inmemory_test
+
+import (
+	"testing"
+
+	"github.com/senseyman/auction-house/service/auction"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(*testing.T) auction.Storage {
+		return inmemory.New()
+	})
+}
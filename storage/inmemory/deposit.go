@@ -0,0 +1,96 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// PostDeposit method saves the deposit of the bidder to the opened order. Deposits of the same bidder are summed up
+func (s *Storage) PostDeposit(ctx context.Context, deposit model.DepositCommand) error {
+	sh := s.lockShard(deposit.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, deposit.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOrderIsOpen(order, deposit.Timestamp); err != nil {
+		return err
+	}
+
+	if deposit.Amount <= 0 {
+		return model.ErrInvalidData
+	}
+
+	if held := getHeldDeposit(order, deposit.UserID); held != nil {
+		held.Amount += deposit.Amount
+	} else {
+		order.Deposits = append(order.Deposits, model.Deposit{
+			UserID:    deposit.UserID,
+			Amount:    deposit.Amount,
+			Timestamp: deposit.Timestamp,
+			Status:    model.DepositStatusHeld,
+		})
+	}
+
+	s.appendAction(&model.OrderAction{
+		Order:     order,
+		Type:      model.OrderActionTypeDeposit,
+		Timestamp: deposit.Timestamp,
+		UserID:    deposit.UserID,
+		BidValue:  deposit.Amount,
+	})
+
+	return nil
+}
+
+// checkDeposit checks the bidder has posted the deposit covering the required percentage of the original reserve price,
+// so lowering the reserve doesn't let in bidders with smaller deposits
+func checkDeposit(order *model.Order, userID int) error {
+	if order.Item.DepositRate == 0 {
+		return nil
+	}
+
+	required := order.OriginalReservePrice.MulDiv(order.Item.DepositRate, model.BasisPointsInUnit)
+	var amount model.Money
+	if held := getHeldDeposit(order, userID); held != nil {
+		amount = held.Amount
+	}
+	if amount < required {
+		return model.ErrDepositRequired
+	}
+
+	return nil
+}
+
+// refundDeposits refunds held deposits of all bidders except the buyer, whose deposit is held until the payment
+func refundDeposits(order *model.Order, timestamp int64, buyerID int) {
+	for idx := range order.Deposits {
+		deposit := &order.Deposits[idx]
+		if deposit.Status == model.DepositStatusHeld && (buyerID == 0 || deposit.UserID != buyerID) {
+			deposit.Status = model.DepositStatusRefunded
+			deposit.ResolvedTime = timestamp
+		}
+	}
+}
+
+// resolveBuyerDeposit refunds the deposit of the buyer who paid or forfeits the deposit of the buyer who defaulted
+func resolveBuyerDeposit(order *model.Order, timestamp int64, buyerID int, status model.DepositStatus) {
+	if deposit := getHeldDeposit(order, buyerID); deposit != nil {
+		deposit.Status = status
+		deposit.ResolvedTime = timestamp
+	}
+}
+
+// getHeldDeposit returns the held deposit of the user
+func getHeldDeposit(order *model.Order, userID int) *model.Deposit {
+	for idx := range order.Deposits {
+		if order.Deposits[idx].UserID == userID && order.Deposits[idx].Status == model.DepositStatusHeld {
+			return &order.Deposits[idx]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_PostDeposit(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+	orders[0].Item.DepositRate = 1000 // 10% of the reserve price 20.00
+
+	s := New()
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+
+	testCases := []struct {
+		name    string
+		deposit model.DepositCommand
+		err     error
+	}{
+		{
+			name:    "err/not_found",
+			deposit: model.DepositCommand{Timestamp: 11, UserID: 3, ItemName: "phone_3", Amount: 200},
+			err:     model.ErrNotFound,
+		},
+		{
+			name:    "err/amount",
+			deposit: model.DepositCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", Amount: 0},
+			err:     model.ErrInvalidData,
+		},
+		{
+			name:    "err/finished_by_time",
+			deposit: model.DepositCommand{Timestamp: 16, UserID: 3, ItemName: "phone_1", Amount: 200},
+			err:     model.ErrAuctionIsFinishedByTime,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.PostDeposit(context.TODO(), tc.deposit)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	// the bid without the deposit is rejected
+	err := s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500})
+	assert.ErrorIs(t, err, model.ErrDepositRequired)
+
+	// deposits of the same bidder are summed up
+	assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", Amount: 100}))
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500})
+	assert.ErrorIs(t, err, model.ErrDepositRequired)
+	assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", Amount: 100}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.Equal(t, []model.Deposit{{UserID: 3, Amount: 200, Timestamp: 11, Status: model.DepositStatusHeld}}, s.getOrder("phone_1").Deposits)
+
+	// the requirement follows the original reserve price, lowering the reserve doesn't lower it
+	assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 12, UserID: 4, ItemName: "phone_1", Amount: 150}))
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 2400})
+	assert.ErrorIs(t, err, model.ErrDepositRequired)
+	assert.NoError(t, s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 13, UserID: 0, ItemName: "phone_1", ReservePrice: 1500}))
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 2400})
+	assert.ErrorIs(t, err, model.ErrDepositRequired)
+	assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", Amount: 50}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 2400}))
+
+	// the listing without the requirement accepts bids without deposits
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 2500}))
+}
+
+func TestStorage_DepositResolution(t *testing.T) {
+	orders := storagetest.GenerateOrders(4)
+
+	s := New()
+	for _, order := range orders {
+		order.Item.DepositRate = 1000
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+		for _, userID := range []int{3, 4, 5} {
+			assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 11, UserID: userID, ItemName: order.Item.Name, Amount: 200}))
+		}
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: order.Item.Name, BidAmount: 3000}))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: order.Item.Name, BidAmount: 2500}))
+	}
+
+	// phone_4 is cancelled before the reserve price is met
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 13, UserID: 3, ItemName: "phone_4"}))
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 13, UserID: 4, ItemName: "phone_4"}))
+	assert.NoError(t, s.CancelOrder(context.TODO(), model.CancelCommand{Timestamp: 14, UserID: 0, ItemName: "phone_4"}))
+
+	// deposits of not winning bidders are refunded at close, the winner's one is held until the payment
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Equal(t, []model.Deposit{
+		{UserID: 3, Amount: 200, Timestamp: 11, Status: model.DepositStatusHeld},
+		{UserID: 4, Amount: 200, Timestamp: 11, Status: model.DepositStatusRefunded, ResolvedTime: 15},
+		{UserID: 5, Amount: 200, Timestamp: 11, Status: model.DepositStatusRefunded, ResolvedTime: 15},
+	}, s.getOrder("phone_1").Deposits)
+
+	// phone_1 is paid, the winner of phone_2 defaults, phone_3 is voided
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 30, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusPaid}))
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 30, UserID: 3, ItemName: "phone_2", Outcome: model.OfferStatusDefaulted}))
+	assert.NoError(t, s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 30, UserID: 99, ItemName: "phone_3"}))
+
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+
+	expStatuses := [][]model.DepositStatus{
+		{model.DepositStatusRefunded, model.DepositStatusRefunded, model.DepositStatusRefunded},
+		{model.DepositStatusForfeited, model.DepositStatusRefunded, model.DepositStatusRefunded},
+		{model.DepositStatusRefunded, model.DepositStatusRefunded, model.DepositStatusRefunded},
+		{model.DepositStatusRefunded, model.DepositStatusRefunded, model.DepositStatusRefunded},
+	}
+	for idx, result := range results {
+		statuses := make([]model.DepositStatus, 0, len(result.Deposits))
+		for _, deposit := range result.Deposits {
+			statuses = append(statuses, deposit.Status)
+		}
+		assert.Equal(t, expStatuses[idx], statuses, result.Item)
+	}
+	assert.Equal(t, int64(30), results[1].Deposits[0].ResolvedTime)
+	assert.Equal(t, int64(14), results[3].Deposits[0].ResolvedTime)
+}
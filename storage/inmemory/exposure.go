@@ -0,0 +1,131 @@
+package inmemory
+
+import (
+	"github.com/senseyman/auction-house/model"
+)
+
+// CreditLimits provides credit limits of users
+type CreditLimits interface {
+	GetCreditLimit(userID int) (model.Money, bool)
+}
+
+// WithCreditLimits sets credit limits restricting the sum of leading bids of a user across open auctions
+func WithCreditLimits(creditLimits CreditLimits) Option {
+	return func(s *Storage) {
+		s.creditLimits = creditLimits
+	}
+}
+
+// exposure is the leading bid of an open order counted in the user exposure
+type exposure struct {
+	userID int
+	amount model.Money // in the reporting currency
+}
+
+// takeLead checks the credit limit and counts the leading bid of the order in the user exposure instead of the previous leader
+func (s *Storage) takeLead(orderName string, userID int, amount model.Money) error {
+	s.usersMx.Lock()
+	defer s.usersMx.Unlock()
+
+	if err := s.checkCreditLimit(orderName, userID, amount); err != nil {
+		return err
+	}
+	s.holdExposure(orderName, userID, amount)
+
+	return nil
+}
+
+// checkCreditLimit checks the user can take the lead in the order with the amount in the reporting currency.
+// The users lock must be held
+func (s *Storage) checkCreditLimit(orderName string, userID int, amount model.Money) error {
+	if s.creditLimits == nil {
+		return nil
+	}
+
+	limit, ok := s.creditLimits.GetCreditLimit(userID)
+	if !ok {
+		return nil
+	}
+
+	userExposure := s.userExposure[userID]
+	if leader, ok := s.leaderExposure[orderName]; ok && leader.userID == userID {
+		// the user raises own leading bid
+		userExposure -= leader.amount
+	}
+
+	if userExposure+amount > limit {
+		return model.ErrCreditLimitExceeded
+	}
+
+	return nil
+}
+
+// holdExposure counts the leading bid of the order in the user exposure instead of the previous leader.
+// The users lock must be held
+func (s *Storage) holdExposure(orderName string, userID int, amount model.Money) {
+	s.dropExposure(orderName)
+
+	s.leaderExposure[orderName] = exposure{userID: userID, amount: amount}
+	s.userExposure[userID] += amount
+}
+
+// releaseExposure removes the leading bid of the order from the user exposure when outbid or closed
+func (s *Storage) releaseExposure(orderName string) {
+	s.usersMx.Lock()
+	defer s.usersMx.Unlock()
+
+	s.dropExposure(orderName)
+}
+
+// dropExposure removes the leading bid of the order from the user exposure. The users lock must be held
+func (s *Storage) dropExposure(orderName string) {
+	leader, ok := s.leaderExposure[orderName]
+	if !ok {
+		return
+	}
+
+	delete(s.leaderExposure, orderName)
+	s.userExposure[leader.userID] -= leader.amount
+	if s.userExposure[leader.userID] == 0 {
+		delete(s.userExposure, leader.userID)
+	}
+}
+
+// refreshExposure recounts the exposure of the current leader of the open order
+func (s *Storage) refreshExposure(order *model.Order) error {
+	leader, err := s.getExposure(order, s.getStatistics(order.Item.Name).leader)
+	if err != nil {
+		s.releaseExposure(order.Item.Name)
+		return err
+	}
+	s.setExposure(order.Item.Name, leader)
+
+	return nil
+}
+
+// getExposure returns the leading bid of the open order in the reporting currency, nil if the order has no leader
+func (s *Storage) getExposure(order *model.Order, leader *model.OrderAction) (*exposure, error) {
+	if order.Status != model.OrderStatusInit || leader == nil {
+		return nil, nil
+	}
+
+	amount, err := s.convert(leader.BidValue, order.Item.Currency, "", leader.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exposure{userID: leader.UserID, amount: amount}, nil
+}
+
+// setExposure counts the leading bid of the order in the user exposure instead of the previous leader,
+// nil releases the exposure of the order
+func (s *Storage) setExposure(orderName string, leader *exposure) {
+	s.usersMx.Lock()
+	defer s.usersMx.Unlock()
+
+	if leader == nil {
+		s.dropExposure(orderName)
+		return
+	}
+	s.holdExposure(orderName, leader.userID, leader.amount)
+}
@@ -0,0 +1,89 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+// testCreditLimits provides credit limits by user id
+type testCreditLimits map[int]model.Money
+
+func (l testCreditLimits) GetCreditLimit(userID int) (model.Money, bool) {
+	limit, ok := l[userID]
+	return limit, ok
+}
+
+func TestStorage_CreditLimit(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+
+	s := New(WithCreditLimits(testCreditLimits{3: 5000}))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+
+	// user 3 leads in two auctions up to the limit
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_1", BidAmount: 2000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 3000}))
+	assert.Equal(t, model.Money(5000), s.userExposure[3])
+
+	// the next leading bid exceeds the limit
+	err := s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_3", BidAmount: 100})
+	assert.ErrorIs(t, err, model.ErrCreditLimitExceeded)
+
+	// raising own leading bid counts the new amount only
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 3100})
+	assert.ErrorIs(t, err, model.ErrCreditLimitExceeded)
+
+	// user without limit outbids user 3 and releases the exposure
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 4, ItemName: "phone_2", BidAmount: 3500}))
+	assert.Equal(t, model.Money(2000), s.userExposure[3])
+	assert.Equal(t, model.Money(3500), s.userExposure[4])
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 3, ItemName: "phone_3", BidAmount: 2500}))
+	assert.Equal(t, model.Money(4500), s.userExposure[3])
+
+	// not leading bid doesn't need the credit
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 3, ItemName: "phone_2", BidAmount: 3400}))
+	assert.Equal(t, model.Money(4500), s.userExposure[3])
+
+	// retraction of the leading bid moves the exposure to the previous leader
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 15, UserID: 4, ItemName: "phone_2"}))
+	assert.Equal(t, model.Money(7900), s.userExposure[3])
+	_, ok := s.userExposure[4]
+	assert.False(t, ok)
+
+	// closed auctions release the exposure
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	assert.Equal(t, model.Money(5900), s.userExposure[3])
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Empty(t, s.userExposure)
+	assert.Empty(t, s.leaderExposure)
+}
+
+func TestStorage_RetractBidExposureFailed(t *testing.T) {
+	order := storagetest.GenerateOrders(1)[0]
+	order.Item.Currency = "EUR"
+	rates := testConverter{"": 100, "EUR": 110}
+
+	s := New(WithCurrencyConverter(rates))
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2000, Currency: "EUR"}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 2500, Currency: "EUR"}))
+	assert.Equal(t, model.Money(2750), s.userExposure[4])
+
+	// the exposure of the previous leader can't be converted, the order is left as it was
+	delete(rates, "EUR")
+	err := s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 14, UserID: 4, ItemName: "phone_1"})
+	assert.ErrorIs(t, err, model.ErrUnsupportedCurrency)
+
+	history := s.getHistory("phone_1")
+	assert.Len(t, history, 3)
+	assert.False(t, history[2].Retracted)
+	assert.Equal(t, model.Money(2500), s.getOrder("phone_1").LastBid)
+	assert.Equal(t, 4, s.getStatistics("phone_1").leader.UserID)
+	assert.Equal(t, model.Money(2750), s.userExposure[4])
+}
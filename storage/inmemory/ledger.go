@@ -0,0 +1,81 @@
+package inmemory
+
+import (
+	"github.com/senseyman/auction-house/model"
+)
+
+// Ledger records money movements of sold orders
+type Ledger interface {
+	Record(event model.LedgerEvent) error
+}
+
+// WithLedger sets the ledger posting entries at close and on payment and settlement events
+func WithLedger(ledger Ledger) Option {
+	return func(s *Storage) {
+		s.ledger = ledger
+	}
+}
+
+// reverseSale reverses the sale to the buyer who has not paid yet
+func (s *Storage) reverseSale(order *model.Order, timestamp int64) error {
+	if order.SettlementStatus != model.SettlementStatusAwaitingPayment {
+		return nil
+	}
+
+	return s.postLedgerEvent(model.LedgerEventSaleReversal, order, timestamp)
+}
+
+// postLedgerEvent records the money movement of the current buyer of the order.
+// With change tracking the event is held until it's saved and posted by PostLedgerEvents
+func (s *Storage) postLedgerEvent(eventType model.LedgerEventType, order *model.Order, timestamp int64) error {
+	if s.ledger == nil {
+		return nil
+	}
+
+	event := model.LedgerEvent{
+		Type:             eventType,
+		Timestamp:        timestamp,
+		Item:             order.Item.Name,
+		Currency:         order.Item.Currency,
+		SellerID:         order.SellerID,
+		BuyerID:          order.WinnerID,
+		Price:            order.CloseBid,
+		BuyerPremium:     order.BuyerPremium,
+		SellerCommission: order.SellerCommission,
+	}
+	if s.trackChanges {
+		s.ledgerMx.Lock()
+		s.ledgerEvents = append(s.ledgerEvents, event)
+		s.ledgerMx.Unlock()
+		return nil
+	}
+
+	return s.ledger.Record(event)
+}
+
+// ChangedLedgerEvents returns ledger events held since the previous call in the order they happened,
+// used by persistent storages to save them together with changed orders
+func (s *Storage) ChangedLedgerEvents() []model.LedgerEvent {
+	s.ledgerMx.Lock()
+	defer s.ledgerMx.Unlock()
+
+	res := s.ledgerEvents
+	s.ledgerEvents = nil
+
+	return res
+}
+
+// PostLedgerEvents records saved ledger events to the ledger
+func (s *Storage) PostLedgerEvents(events []model.LedgerEvent) error {
+	if s.ledger == nil {
+		return nil
+	}
+
+	for _, event := range events {
+		if err := s.ledger.Record(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/ledger"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_Ledger(t *testing.T) {
+	orders := storagetest.GenerateOrders(7)
+	orders[0].CloseTime = 1000 // to keep the payment deadline of phone_1 not passed
+	orders[6].Item.Currency = "EUR"
+
+	l := ledger.New()
+	s := New(
+		WithLedger(l),
+		WithFeeCalculator(testFeeCalculator{}),
+		WithCurrencyConverter(testConverter{"": 100, "EUR": 110}),
+		WithPaymentDeadline(100),
+	)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: order.Item.Name, BidAmount: 3000}))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 4, ItemName: order.Item.Name, BidAmount: 2500}))
+	}
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	// phone_1 is awaiting the payment
+	// phone_2 is paid
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 50, UserID: 3, ItemName: "phone_2", Outcome: model.OfferStatusPaid}))
+	// phone_3 is settled
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 50, UserID: 3, ItemName: "phone_3", Outcome: model.OfferStatusPaid}))
+	assert.NoError(t, s.SettleOrder(context.TODO(), model.AdminCommand{Timestamp: 51, UserID: 99, ItemName: "phone_3"}))
+	// phone_4 is refunded
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 50, UserID: 3, ItemName: "phone_4", Outcome: model.OfferStatusPaid}))
+	assert.NoError(t, s.RefundOrder(context.TODO(), model.AdminCommand{Timestamp: 51, UserID: 99, ItemName: "phone_4"}))
+	// phone_5 is paid by the second-chance buyer
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 50, UserID: 3, ItemName: "phone_5", Outcome: model.OfferStatusDefaulted}))
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 51, UserID: 4, ItemName: "phone_5", Outcome: model.OfferStatusPaid}))
+	// phone_6 is voided before the payment
+	assert.NoError(t, s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 50, UserID: 99, ItemName: "phone_6"}))
+	// phone_7 in EUR is defaulted by both buyers missing deadlines
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 500))
+
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+
+	var soldCount int
+	for _, result := range results {
+		if result.Status == model.OrderStatusSold {
+			soldCount++
+		}
+		assert.NoError(t, l.Reconcile(result), result.Item)
+	}
+	assert.Equal(t, 5, soldCount)
+
+	// every currency is balanced
+	totals := make(map[model.Currency]model.Money)
+	for _, balance := range l.TrialBalance() {
+		totals[balance.Currency] += balance.Debit - balance.Credit
+	}
+	for currency, total := range totals {
+		assert.Zero(t, total, currency)
+	}
+}
@@ -0,0 +1,200 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// WithPaymentDeadline sets the number of seconds a buyer has to pay since the offer, 0 means no deadline
+func WithPaymentDeadline(seconds int64) Option {
+	return func(s *Storage) {
+		s.paymentDeadline = seconds
+	}
+}
+
+// RecordPayment method saves the payment outcome of the current buyer of the sold order.
+// If the buyer defaults, the item is offered to the next-highest bidder at their own bid.
+func (s *Storage) RecordPayment(ctx context.Context, payment model.PaymentCommand) error {
+	s.lockAll()
+	defer s.unlockAll()
+
+	order, err := s.loadOrder(ctx, s.shardOf(payment.ItemName), payment.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != model.OrderStatusSold || len(order.Offers) == 0 {
+		return model.ErrOrderIsNotSold
+	}
+
+	offer := &order.Offers[len(order.Offers)-1]
+	if offer.UserID != payment.UserID {
+		return model.ErrNotBuyer
+	}
+	if offer.Status != model.OfferStatusPending {
+		return model.ErrOfferIsNotPending
+	}
+	if offer.Deadline > 0 && payment.Timestamp > offer.Deadline {
+		// the offer is defaulted by the next heartbeat
+		return model.ErrOfferIsNotPending
+	}
+
+	switch payment.Outcome {
+	case model.OfferStatusPaid:
+		offer.Status = model.OfferStatusPaid
+		order.SettlementStatus = model.SettlementStatusPaid
+		resolveBuyerDeposit(order, payment.Timestamp, offer.UserID, model.DepositStatusRefunded)
+		s.addPaymentAction(order, payment.Timestamp, offer)
+		return s.postLedgerEvent(model.LedgerEventPayment, order, payment.Timestamp)
+	case model.OfferStatusDefaulted:
+		return s.defaultOffer(order, payment.Timestamp)
+	default:
+		return model.ErrInvalidData
+	}
+}
+
+// RefundOrder method returns the payment to the buyer of the paid order
+func (s *Storage) RefundOrder(ctx context.Context, cmd model.AdminCommand) error {
+	return s.changeSettlementStatus(ctx, cmd, model.SettlementStatusRefunded, model.OrderActionTypeRefund, model.LedgerEventRefund)
+}
+
+// SettleOrder method pays out the paid order to the seller
+func (s *Storage) SettleOrder(ctx context.Context, cmd model.AdminCommand) error {
+	return s.changeSettlementStatus(ctx, cmd, model.SettlementStatusSettled, model.OrderActionTypeSettle, model.LedgerEventSettlement)
+}
+
+// changeSettlementStatus moves the paid order to the final settlement status
+func (s *Storage) changeSettlementStatus(
+	ctx context.Context,
+	cmd model.AdminCommand,
+	status model.SettlementStatus,
+	actionType model.OrderActionType,
+	eventType model.LedgerEventType,
+) error {
+	sh := s.lockShard(cmd.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, cmd.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if order.SettlementStatus != model.SettlementStatusPaid {
+		return model.ErrInvalidSettlementStatus
+	}
+
+	order.SettlementStatus = status
+	s.addAuditAction(order, actionType, cmd.Timestamp, cmd.UserID)
+
+	return s.postLedgerEvent(eventType, order, cmd.Timestamp)
+}
+
+// expireOffers defaults buyers who have not paid by the deadline
+func (s *Storage) expireOffers(timestamp int64) error {
+	for {
+		// every default may make the next offer which is also expired
+		next, ok := s.popDue(func(sh *shard) *deadlineQueue { return &sh.offerQueue }, func(deadline int64) bool {
+			return timestamp > deadline
+		})
+		if !ok {
+			return nil
+		}
+
+		order := s.getOrder(next.name)
+		if order == nil || order.SettlementStatus != model.SettlementStatusAwaitingPayment ||
+			order.Offers[len(order.Offers)-1].Deadline != next.time {
+			// the offer is paid, defaulted or voided
+			continue
+		}
+		if err := s.defaultOffer(order, next.time); err != nil {
+			return err
+		}
+	}
+}
+
+// defaultOffer defaults the current buyer and offers the item to the next-highest bidder.
+// The order is unsold if nobody else is eligible to buy the item
+func (s *Storage) defaultOffer(order *model.Order, timestamp int64) error {
+	if err := s.reverseSale(order, timestamp); err != nil {
+		return err
+	}
+
+	offer := &order.Offers[len(order.Offers)-1]
+	offer.Status = model.OfferStatusDefaulted
+	resolveBuyerDeposit(order, timestamp, offer.UserID, model.DepositStatusForfeited)
+	s.addPaymentAction(order, timestamp, offer)
+
+	next := s.getSecondChanceBid(order)
+	if next == nil {
+		order.Status = model.OrderStatusUnsold
+		order.SettlementStatus = model.SettlementStatusDefaulted
+		s.setBuyer(order, 0, 0)
+		return nil
+	}
+
+	s.setBuyer(order, next.UserID, next.BidValue)
+	order.Offers = append(order.Offers, s.newOffer(next.UserID, next.BidValue, timestamp))
+	s.scheduleOffer(order)
+	s.appendAction(&model.OrderAction{
+		Order:     order,
+		Type:      model.OrderActionTypeSecondChanceOffer,
+		Timestamp: timestamp,
+		UserID:    next.UserID,
+		BidValue:  next.BidValue,
+	})
+
+	if err := s.postLedgerEvent(model.LedgerEventSale, order, timestamp); err != nil {
+		return err
+	}
+
+	return s.dropBidsOnMaxWins(next.UserID, timestamp)
+}
+
+// newOffer makes the pending offer to buy the item with the payment deadline
+func (s *Storage) newOffer(userID int, price model.Money, timestamp int64) model.Offer {
+	offer := model.Offer{
+		UserID:    userID,
+		Price:     price,
+		Timestamp: timestamp,
+		Status:    model.OfferStatusPending,
+	}
+	if s.paymentDeadline > 0 {
+		offer.Deadline = timestamp + s.paymentDeadline
+	}
+
+	return offer
+}
+
+// addPaymentAction saves the payment outcome of the offer to the history data
+func (s *Storage) addPaymentAction(order *model.Order, timestamp int64, offer *model.Offer) {
+	s.appendAction(&model.OrderAction{
+		Order:     order,
+		Type:      model.OrderActionTypePayment,
+		Timestamp: timestamp,
+		UserID:    offer.UserID,
+		BidValue:  offer.Price,
+		Outcome:   offer.Status,
+	})
+}
+
+// getSecondChanceBid returns the highest active bid reaching the reserve price of the bidder
+// who has not been offered the item yet and can win one more item. The earliest bid wins if amounts are equal
+func (s *Storage) getSecondChanceBid(order *model.Order) *model.OrderAction {
+	offered := make(map[int]bool, len(order.Offers))
+	for _, offer := range order.Offers {
+		offered[offer.UserID] = true
+	}
+
+	var next *model.OrderAction
+	for _, bid := range getActiveBids(s.getHistory(order.Item.Name)) {
+		if offered[bid.UserID] || bid.BidValue < order.Item.ReservePrice || s.hasMaxWins(bid.UserID) {
+			continue
+		}
+		if next == nil || bid.BidValue > next.BidValue {
+			next = bid
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,157 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_RecordPayment(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New(WithFeeCalculator(testFeeCalculator{}))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[1]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 1500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 5, ItemName: "phone_1", BidAmount: 2200}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 6, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 2500}))
+
+	// payments are accepted for sold orders only
+	err := s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 14, UserID: 6, ItemName: "phone_1", Outcome: model.OfferStatusPaid})
+	assert.ErrorIs(t, err, model.ErrOrderIsNotSold)
+	err = s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 14, UserID: 6, ItemName: "phone_3", Outcome: model.OfferStatusPaid})
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	// only the current buyer pays
+	err = s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 21, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusPaid})
+	assert.ErrorIs(t, err, model.ErrNotBuyer)
+	err = s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 21, UserID: 6, ItemName: "phone_1", Outcome: model.OfferStatusPending})
+	assert.ErrorIs(t, err, model.ErrInvalidData)
+
+	// the winner defaults and the item is offered to the next-highest bidder at their own bid
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 21, UserID: 6, ItemName: "phone_1", Outcome: model.OfferStatusDefaulted}))
+	err = s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 22, UserID: 6, ItemName: "phone_1", Outcome: model.OfferStatusPaid})
+	assert.ErrorIs(t, err, model.ErrNotBuyer)
+
+	// the next bidder defaults too, the bidder below the reserve price is not eligible
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 22, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusDefaulted}))
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 23, UserID: 5, ItemName: "phone_1", Outcome: model.OfferStatusPaid}))
+	err = s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 24, UserID: 5, ItemName: "phone_1", Outcome: model.OfferStatusDefaulted})
+	assert.ErrorIs(t, err, model.ErrOfferIsNotPending)
+
+	// the only bidder defaults and nobody else can buy the item
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 24, UserID: 3, ItemName: "phone_2", Outcome: model.OfferStatusDefaulted}))
+
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+
+	assert.Equal(t, model.OrderStatusSold, results[0].Status)
+	assert.Equal(t, model.SettlementStatusPaid, results[0].SettlementStatus)
+	assert.Equal(t, 5, results[0].UserID)
+	assert.Equal(t, model.Money(2200), results[0].PricePaid)
+	assert.Equal(t, model.Money(220), results[0].BuyerPremium)
+	assert.Equal(t, model.Money(110), results[0].SellerCommission)
+	assert.Equal(t, []model.Offer{
+		{UserID: 6, Price: 2500, Timestamp: 15, Status: model.OfferStatusDefaulted},
+		{UserID: 3, Price: 2500, Timestamp: 21, Status: model.OfferStatusDefaulted},
+		{UserID: 5, Price: 2200, Timestamp: 22, Status: model.OfferStatusPaid},
+	}, results[0].Offers)
+
+	assert.Equal(t, model.OrderStatusUnsold, results[1].Status)
+	assert.Equal(t, model.SettlementStatusDefaulted, results[1].SettlementStatus)
+	assert.Equal(t, 0, results[1].UserID)
+	assert.Equal(t, model.Money(0), results[1].PricePaid)
+	assert.Equal(t, model.Money(0), results[1].BuyerPremium)
+	assert.Equal(t, []model.Offer{
+		{UserID: 3, Price: 2000, Timestamp: 20, Status: model.OfferStatusDefaulted},
+	}, results[1].Offers)
+}
+
+func TestStorage_Settlement(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+
+	s := New()
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: order.Item.Name, BidAmount: 2500}))
+	}
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Equal(t, model.SettlementStatusAwaitingPayment, s.getOrder("phone_1").SettlementStatus)
+
+	// only paid orders are settled or refunded
+	err := s.SettleOrder(context.TODO(), model.AdminCommand{Timestamp: 30, UserID: 99, ItemName: "phone_1"})
+	assert.ErrorIs(t, err, model.ErrInvalidSettlementStatus)
+	err = s.RefundOrder(context.TODO(), model.AdminCommand{Timestamp: 30, UserID: 99, ItemName: "phone_1"})
+	assert.ErrorIs(t, err, model.ErrInvalidSettlementStatus)
+	err = s.SettleOrder(context.TODO(), model.AdminCommand{Timestamp: 30, UserID: 99, ItemName: "phone_4"})
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	for _, order := range orders {
+		assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 30, UserID: 3, ItemName: order.Item.Name, Outcome: model.OfferStatusPaid}))
+	}
+	assert.NoError(t, s.SettleOrder(context.TODO(), model.AdminCommand{Timestamp: 31, UserID: 99, ItemName: "phone_1"}))
+	assert.NoError(t, s.RefundOrder(context.TODO(), model.AdminCommand{Timestamp: 31, UserID: 99, ItemName: "phone_2"}))
+
+	// final statuses can't be changed
+	err = s.RefundOrder(context.TODO(), model.AdminCommand{Timestamp: 32, UserID: 99, ItemName: "phone_1"})
+	assert.ErrorIs(t, err, model.ErrInvalidSettlementStatus)
+	err = s.SettleOrder(context.TODO(), model.AdminCommand{Timestamp: 32, UserID: 99, ItemName: "phone_2"})
+	assert.ErrorIs(t, err, model.ErrInvalidSettlementStatus)
+
+	// paid orders can't be voided or reopened until refunded
+	err = s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 32, UserID: 99, ItemName: "phone_3"})
+	assert.ErrorIs(t, err, model.ErrInvalidSettlementStatus)
+	err = s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 32, UserID: 99, ItemName: "phone_1", CloseTime: 40})
+	assert.ErrorIs(t, err, model.ErrInvalidSettlementStatus)
+	assert.NoError(t, s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 32, UserID: 99, ItemName: "phone_2"}))
+
+	assert.Equal(t, model.SettlementStatusSettled, s.getOrder("phone_1").SettlementStatus)
+	assert.Equal(t, model.SettlementStatus(""), s.getOrder("phone_2").SettlementStatus)
+	assert.Equal(t, model.SettlementStatusPaid, s.getOrder("phone_3").SettlementStatus)
+
+	// the changes are recorded in the auction history
+	lastAction := s.getHistory("phone_1")[len(s.getHistory("phone_1"))-1]
+	assert.Equal(t, model.OrderActionTypeSettle, lastAction.Type)
+	assert.Equal(t, int64(31), lastAction.Timestamp)
+	assert.Equal(t, 99, lastAction.UserID)
+}
+
+func TestStorage_PaymentDeadline(t *testing.T) {
+	orders := storagetest.GenerateOrders(1)
+
+	s := New(WithPaymentDeadline(10))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 2800}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 5, ItemName: "phone_1", BidAmount: 2600}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+
+	// the deadline is not passed yet
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 25))
+	assert.Len(t, s.getOrder("phone_1").Offers, 1)
+
+	// the payment after the deadline is rejected
+	err := s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 26, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusPaid})
+	assert.ErrorIs(t, err, model.ErrOfferIsNotPending)
+
+	// the heartbeat defaults both buyers who missed their deadlines
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 36))
+	assert.Equal(t, []model.Offer{
+		{UserID: 3, Price: 2800, Timestamp: 15, Deadline: 25, Status: model.OfferStatusDefaulted},
+		{UserID: 4, Price: 2800, Timestamp: 25, Deadline: 35, Status: model.OfferStatusDefaulted},
+		{UserID: 5, Price: 2600, Timestamp: 35, Deadline: 45, Status: model.OfferStatusPending},
+	}, s.getOrder("phone_1").Offers)
+	assert.Equal(t, model.SettlementStatusAwaitingPayment, s.getOrder("phone_1").SettlementStatus)
+
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 46))
+	assert.Equal(t, model.OrderStatusUnsold, s.getOrder("phone_1").Status)
+	assert.Equal(t, model.SettlementStatusDefaulted, s.getOrder("phone_1").SettlementStatus)
+}
@@ -0,0 +1,145 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// GetOrder provides the order with its current leader and price, archived orders included
+func (s *Storage) GetOrder(ctx context.Context, itemName string) (model.AuctionView, error) {
+	sh := s.lockShard(itemName)
+	defer sh.mx.Unlock()
+
+	if order, ok := sh.orders[itemName]; ok {
+		return newAuctionView(order, sh.statistics[itemName]), nil
+	}
+
+	// statistics of archived orders are counted from the archived history
+	order, history, err := s.readOrder(ctx, sh, itemName)
+	if err != nil {
+		return model.AuctionView{}, err
+	}
+
+	return newAuctionView(order, countBids(history)), nil
+}
+
+// ListOpenAuctions provides open orders matching the filter in the close order: by close time, creation time and name
+func (s *Storage) ListOpenAuctions(_ context.Context, filter model.AuctionFilter) ([]model.AuctionView, error) {
+	s.lockAll()
+	defer s.unlockAll()
+
+	var deadlines []deadline
+	for _, sh := range s.shards {
+		for _, order := range sh.orders {
+			if order.Status != model.OrderStatusInit || !matchFilter(order, filter) {
+				continue
+			}
+			deadlines = append(deadlines, deadline{
+				time:         order.CloseTime,
+				creationTime: order.CreationTime,
+				name:         order.Item.Name,
+			})
+		}
+	}
+	sort.Slice(deadlines, func(i, j int) bool {
+		return deadlines[i].before(deadlines[j])
+	})
+
+	views := make([]model.AuctionView, 0, len(deadlines))
+	for _, next := range deadlines {
+		views = append(views, newAuctionView(s.getOrder(next.name), s.getStatistics(next.name)))
+	}
+
+	return views, nil
+}
+
+// GetBidHistory provides the page of bids on the order in the order they were placed, archived orders included
+func (s *Storage) GetBidHistory(ctx context.Context, itemName string, page model.Page) (model.BidHistory, error) {
+	if page.Offset < 0 || page.Limit < 0 {
+		return model.BidHistory{}, model.ErrInvalidData
+	}
+
+	sh := s.lockShard(itemName)
+	defer sh.mx.Unlock()
+
+	_, history, err := s.readOrder(ctx, sh, itemName)
+	if err != nil {
+		return model.BidHistory{}, err
+	}
+
+	res := model.BidHistory{Bids: make([]model.OrderAction, 0)}
+	for _, action := range history {
+		if action.Type != model.OrderActionTypeBid {
+			continue
+		}
+		res.Total++
+		if res.Total <= page.Offset || (page.Limit > 0 && len(res.Bids) == page.Limit) {
+			continue
+		}
+
+		bid := *action
+		bid.Order = nil
+		res.Bids = append(res.Bids, bid)
+	}
+
+	return res, nil
+}
+
+// readOrder returns the order with its auction history, the archived order is read without loading it back.
+// The shard of the order must be locked
+func (s *Storage) readOrder(ctx context.Context, sh *shard, orderName string) (*model.Order, []*model.OrderAction, error) {
+	if order, ok := sh.orders[orderName]; ok {
+		return order, sh.auctionHistory[orderName], nil
+	}
+	if _, ok := sh.summaries[orderName]; !ok {
+		return nil, nil, model.ErrNotFound
+	}
+
+	state, err := s.archive.Get(ctx, orderName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s from archive: %w", orderName, err)
+	}
+	history := make([]*model.OrderAction, 0, len(state.History))
+	for idx := range state.History {
+		history = append(history, &state.History[idx])
+	}
+
+	return &state.Order, history, nil
+}
+
+// newAuctionView returns the copy of the order with its current leader and price
+func newAuctionView(order *model.Order, statistics bidStatistics) model.AuctionView {
+	view := model.AuctionView{
+		Order:      copyOrder(*order),
+		Price:      order.CloseBid,
+		Statistics: statistics.result(),
+	}
+	if order.Status == model.OrderStatusInit {
+		view.Price = order.LastBid
+		if statistics.leader != nil {
+			view.LeaderID = statistics.leader.UserID
+		}
+	} else {
+		view.LeaderID = order.WinnerID
+	}
+
+	return view
+}
+
+// matchFilter checks the open order matches the filter
+func matchFilter(order *model.Order, filter model.AuctionFilter) bool {
+	if filter.ClosingBefore != 0 && order.CloseTime >= filter.ClosingBefore {
+		return false
+	}
+	if filter.SellerID != 0 && order.SellerID != filter.SellerID {
+		return false
+	}
+	if filter.ReserveMet != nil && isReserveMet(order) != *filter.ReserveMet {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,41 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_QueryArchived(t *testing.T) {
+	orders := storagetest.GenerateOrders(1)
+	archive := testArchive{}
+
+	s := New(WithArchive(archive, 0))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 1500}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	require.Len(t, archive, 1)
+
+	// the archived order is read without loading it back
+	view, err := s.GetOrder(context.TODO(), "phone_1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusUnsold, view.Order.Status)
+	assert.Equal(t, 0, view.LeaderID)
+	assert.Equal(t, 1, view.Statistics.TotalBidCount)
+
+	history, err := s.GetBidHistory(context.TODO(), "phone_1", model.Page{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, history.Total)
+	assert.Equal(t, model.Money(1500), history.Bids[0].BidValue)
+	assert.Nil(t, history.Bids[0].Order)
+	assert.Nil(t, s.getOrder("phone_1"))
+
+	views, err := s.ListOpenAuctions(context.TODO(), model.AuctionFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, views)
+}
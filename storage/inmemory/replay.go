@@ -0,0 +1,145 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// GetOrderAt provides the state of the order as of the time by replaying its auction history, archived orders included.
+// The auction is considered closed by time right after its close time
+func (s *Storage) GetOrderAt(ctx context.Context, itemName string, timestamp int64) (model.AuctionState, error) {
+	sh := s.lockShard(itemName)
+	defer sh.mx.Unlock()
+
+	_, history, err := s.readOrder(ctx, sh, itemName)
+	if err != nil {
+		return model.AuctionState{}, err
+	}
+
+	return replayHistory(itemName, history, timestamp)
+}
+
+// auctionReplay is the state of the auction rebuilt from its history action by action
+type auctionReplay struct {
+	state      model.AuctionState
+	order      model.Order         // status, close time and leading bid of the order as of the replayed time
+	history    []model.OrderAction // replayed actions, bids are retracted and dropped as of the replayed time
+	statistics bidStatistics       // statistics of replayed bids, recounted when bids are retracted or dropped
+}
+
+// replayHistory applies actions of the auction history up to the time in the order they were saved.
+// Timestamps of the history are not monotonic: bids are dropped at the close time of the won order
+// and offers are made at the payment deadline, both saved by the later heartbeat
+func replayHistory(itemName string, history []*model.OrderAction, timestamp int64) (model.AuctionState, error) {
+	if len(history) == 0 || history[0].Timestamp > timestamp {
+		// the order is not created yet
+		return model.AuctionState{}, model.ErrNotFound
+	}
+
+	r := &auctionReplay{state: model.AuctionState{Timestamp: timestamp, Item: itemName}}
+	for _, action := range history {
+		if action.Timestamp > timestamp {
+			continue
+		}
+		// commands of admins may come after the close time before the auction is closed by the heartbeat
+		if action.Type != model.OrderActionTypeExtend && action.Type != model.OrderActionTypeForceClose {
+			r.closeByTime(action.Timestamp)
+		}
+		r.apply(action)
+	}
+	r.closeByTime(timestamp)
+	r.state.Status = r.order.Status
+
+	return r.state, nil
+}
+
+// apply changes the state by the action
+func (r *auctionReplay) apply(action *model.OrderAction) {
+	r.history = AppendAction(r.history, *action)
+
+	switch action.Type {
+	case model.OrderActionTypeInit:
+		r.order.Status = model.OrderStatusInit
+		r.order.CloseTime = action.CloseTime
+	case model.OrderActionTypeBid:
+		r.statistics.add(&r.history[len(r.history)-1])
+	case model.OrderActionTypeRetract, model.OrderActionTypeBidsDrop:
+		// bids are recounted the same way the storage does after they are retracted or dropped
+		r.statistics = countBids(r.pointers())
+	case model.OrderActionTypeExtend:
+		r.order.CloseTime = action.CloseTime
+	case model.OrderActionTypeForceClose:
+		r.order.CloseTime = action.CloseTime
+		r.close()
+	case model.OrderActionTypeReopen:
+		r.order.CloseTime = action.CloseTime
+		r.order.Status = model.OrderStatusInit
+	case model.OrderActionTypeCancel:
+		r.order.Status = model.OrderStatusCancelled
+	case model.OrderActionTypeVoid:
+		r.order.Status = model.OrderStatusVoided
+		r.state.LeaderID = 0
+		r.state.Price = 0
+	case model.OrderActionTypePayment:
+		// the defaulted order is unsold unless the item is offered to the next bidder by the next action
+		if action.Outcome == model.OfferStatusDefaulted {
+			r.order.Status = model.OrderStatusUnsold
+			r.state.LeaderID = 0
+			r.state.Price = 0
+		}
+	case model.OrderActionTypeSecondChanceOffer:
+		r.order.Status = model.OrderStatusSold
+		r.state.LeaderID = action.UserID
+		r.state.Price = action.BidValue
+	}
+
+	r.count()
+}
+
+// count sets the bid count, the leader and the price of the open auction are the leading bid
+func (r *auctionReplay) count() {
+	r.state.BidCount = r.statistics.count
+	r.order.LastBid = r.statistics.leading()
+
+	if r.order.Status != model.OrderStatusInit {
+		return
+	}
+	r.state.LeaderID = 0
+	r.state.Price = r.order.LastBid
+	if r.statistics.leader != nil {
+		r.state.LeaderID = r.statistics.leader.UserID
+	}
+}
+
+// closeByTime closes the open auction if the time is after its close time
+func (r *auctionReplay) closeByTime(timestamp int64) {
+	if r.order.Status == model.OrderStatusInit && timestamp > r.order.CloseTime {
+		r.close()
+	}
+}
+
+// close closes the open auction the same way the storage does
+func (r *auctionReplay) close() {
+	if r.order.Status != model.OrderStatusInit {
+		return
+	}
+
+	history := r.pointers()
+	winner, price := closeAuction(&r.order, history, countBids(history))
+	r.state.LeaderID = 0
+	r.state.Price = price
+	if winner != nil {
+		r.state.LeaderID = winner.UserID
+	}
+}
+
+// pointers returns pointers to replayed actions the way the storage keeps the auction history
+func (r *auctionReplay) pointers() []*model.OrderAction {
+	history := make([]*model.OrderAction, 0, len(r.history))
+	for idx := range r.history {
+		history = append(history, &r.history[idx])
+	}
+
+	return history
+}
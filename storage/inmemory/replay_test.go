@@ -0,0 +1,146 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_GetOrderAt(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+	admin := 99
+
+	s := New(WithMaxWinsPerBidder(1))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	bids := []model.BidCommand{
+		{Timestamp: 13, UserID: 3, ItemName: "phone_1", BidAmount: 3000},
+		{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 4000},
+		{Timestamp: 13, UserID: 4, ItemName: "phone_2", BidAmount: 3500},
+		{Timestamp: 13, UserID: 5, ItemName: "phone_2", BidAmount: 2200},
+		{Timestamp: 13, UserID: 6, ItemName: "phone_3", BidAmount: 2500},
+	}
+	for _, bid := range bids {
+		assert.NoError(t, s.BidOrder(context.TODO(), bid))
+	}
+
+	// phone_3 is force closed, reopened and voided
+	assert.NoError(t, s.ForceCloseAuction(context.TODO(), model.AdminCommand{Timestamp: 14, UserID: admin, ItemName: "phone_3"}))
+	// user 3 wins phone_1, their bid on phone_2 is dropped
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	// the buyer of phone_2 defaults, the item is offered to the next bidder
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 21))
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 22, UserID: 4, ItemName: "phone_2", Outcome: model.OfferStatusDefaulted}))
+	// the next bidder defaults too, nobody else is eligible to buy the item
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 24, UserID: 5, ItemName: "phone_2", Outcome: model.OfferStatusDefaulted}))
+	assert.NoError(t, s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 23, UserID: admin, ItemName: "phone_3", CloseTime: 40}))
+	assert.NoError(t, s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 30, UserID: admin, ItemName: "phone_3"}))
+
+	testCases := []struct {
+		name      string
+		item      string
+		timestamp int64
+		exp       model.AuctionState
+	}{
+		{
+			name:      "dropped",
+			item:      "phone_2",
+			timestamp: 16,
+			exp:       model.AuctionState{Status: model.OrderStatusInit, LeaderID: 4, Price: 3500, BidCount: 3},
+		},
+		{
+			name:      "sold",
+			item:      "phone_2",
+			timestamp: 21,
+			exp:       model.AuctionState{Status: model.OrderStatusSold, LeaderID: 4, Price: 2200, BidCount: 3},
+		},
+		{
+			name:      "second_chance_offer",
+			item:      "phone_2",
+			timestamp: 22,
+			exp:       model.AuctionState{Status: model.OrderStatusSold, LeaderID: 5, Price: 2200, BidCount: 3},
+		},
+		{
+			name:      "defaulted",
+			item:      "phone_2",
+			timestamp: 24,
+			exp:       model.AuctionState{Status: model.OrderStatusUnsold, BidCount: 3},
+		},
+		{
+			name:      "force_closed",
+			item:      "phone_3",
+			timestamp: 14,
+			exp:       model.AuctionState{Status: model.OrderStatusSold, LeaderID: 6, Price: 2000, BidCount: 1},
+		},
+		{
+			name:      "reopened",
+			item:      "phone_3",
+			timestamp: 25,
+			exp:       model.AuctionState{Status: model.OrderStatusInit, LeaderID: 6, Price: 2500, BidCount: 1},
+		},
+		{
+			name:      "voided",
+			item:      "phone_3",
+			timestamp: 30,
+			exp:       model.AuctionState{Status: model.OrderStatusVoided, BidCount: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state, err := s.GetOrderAt(context.TODO(), tc.item, tc.timestamp)
+			assert.NoError(t, err)
+			tc.exp.Timestamp = tc.timestamp
+			tc.exp.Item = tc.item
+			assert.Equal(t, tc.exp, state)
+		})
+	}
+
+	// the replay up to now gives the current state
+	for _, order := range orders {
+		state, err := s.GetOrderAt(context.TODO(), order.Item.Name, 100)
+		assert.NoError(t, err)
+		current := s.getOrder(order.Item.Name)
+		assert.Equal(t, current.Status, state.Status)
+		assert.Equal(t, current.WinnerID, state.LeaderID)
+		assert.Equal(t, current.CloseBid, state.Price)
+		assert.Equal(t, s.getStatistics(order.Item.Name).count, state.BidCount)
+	}
+}
+
+func TestStorage_GetOrderAtOutOfOrder(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New(WithMaxWinsPerBidder(1))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 3000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 17, UserID: 4, ItemName: "phone_2", BidAmount: 2600}))
+	// user 3 wins phone_1 closed at 15 by the later heartbeat, their bid on phone_2 is dropped
+	// at the close time after the bid of user 4 is saved
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 18))
+	history := s.getHistory("phone_2")
+	require.Equal(t, model.OrderActionTypeBidsDrop, history[len(history)-1].Type)
+	require.Equal(t, int64(15), history[len(history)-1].Timestamp)
+
+	state, err := s.GetOrderAt(context.TODO(), "phone_2", 16)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuctionState{Timestamp: 16, Item: "phone_2", Status: model.OrderStatusInit, BidCount: 1}, state)
+
+	state, err = s.GetOrderAt(context.TODO(), "phone_2", 17)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuctionState{Timestamp: 17, Item: "phone_2", Status: model.OrderStatusInit, LeaderID: 4, Price: 2600, BidCount: 2}, state)
+
+	// the auction is closed with the dropped bid excluded from the price
+	state, err = s.GetOrderAt(context.TODO(), "phone_2", 21)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuctionState{Timestamp: 21, Item: "phone_2", Status: model.OrderStatusSold, LeaderID: 4, Price: 2000, BidCount: 2}, state)
+}
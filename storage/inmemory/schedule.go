@@ -0,0 +1,107 @@
+package inmemory
+
+import (
+	"container/heap"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// deadline is the time the order is due to be processed
+type deadline struct {
+	time         int64
+	creationTime int64
+	name         string
+}
+
+// before checks the deadline is due earlier than the other one
+func (d deadline) before(other deadline) bool {
+	if d.time != other.time {
+		return d.time < other.time
+	}
+	if d.creationTime != other.creationTime {
+		return d.creationTime < other.creationTime
+	}
+	return d.name < other.name
+}
+
+// deadlineQueue is the min-heap of deadlines ordered by time, creation time and order name.
+// Deadlines are not removed when the order changes, outdated ones are skipped when popped
+type deadlineQueue []deadline
+
+func (q deadlineQueue) Len() int { return len(q) }
+
+func (q deadlineQueue) Less(i, j int) bool { return q[i].before(q[j]) }
+
+func (q deadlineQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *deadlineQueue) Push(x any) { *q = append(*q, x.(deadline)) }
+
+func (q *deadlineQueue) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return last
+}
+
+// popDue removes and returns the earliest deadline if it's due
+func (q *deadlineQueue) popDue(due func(time int64) bool) (deadline, bool) {
+	if len(*q) == 0 || !due((*q)[0].time) {
+		return deadline{}, false
+	}
+
+	return heap.Pop(q).(deadline), true
+}
+
+// scheduleClose adds the close time of the opened order to the close queue of its shard
+func (s *Storage) scheduleClose(order *model.Order) {
+	if order.Status != model.OrderStatusInit {
+		return
+	}
+
+	heap.Push(&s.shardOf(order.Item.Name).closeQueue, deadline{
+		time:         order.CloseTime,
+		creationTime: order.CreationTime,
+		name:         order.Item.Name,
+	})
+}
+
+// scheduleOffer adds the payment deadline of the pending offer to the offer queue of its shard
+func (s *Storage) scheduleOffer(order *model.Order) {
+	if order.SettlementStatus != model.SettlementStatusAwaitingPayment {
+		return
+	}
+
+	offer := order.Offers[len(order.Offers)-1]
+	if offer.Deadline == 0 {
+		return
+	}
+
+	heap.Push(&s.shardOf(order.Item.Name).offerQueue, deadline{
+		time:         offer.Deadline,
+		creationTime: order.CreationTime,
+		name:         order.Item.Name,
+	})
+}
+
+// closeOrders closes opened orders whose close time is due in the close order: by close time, creation time and name.
+// Closing an order may change results of the next ones. All shards must be locked
+func (s *Storage) closeOrders(due func(closeTime int64) bool) error {
+	for {
+		next, ok := s.popDue(func(sh *shard) *deadlineQueue { return &sh.closeQueue }, due)
+		if !ok {
+			return nil
+		}
+
+		order := s.getOrder(next.name)
+		if order == nil || order.Status != model.OrderStatusInit || order.CloseTime != next.time {
+			// the order is closed or its close time is moved
+			continue
+		}
+		if err := s.closeOrder(order); err != nil {
+			// the deadline is kept for the next heartbeat
+			heap.Push(&s.shardOf(next.name).closeQueue, next)
+			return err
+		}
+	}
+}
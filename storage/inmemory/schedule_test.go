@@ -0,0 +1,171 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_CloseQueue(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+	admin := 99
+
+	s := New(WithChangeTracking())
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_3", BidAmount: 2500}))
+
+	// phone_1 is extended, phone_2 is closed by the admin
+	assert.NoError(t, s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 14, UserID: admin, ItemName: "phone_1", CloseTime: 30}))
+	assert.NoError(t, s.ForceCloseAuction(context.TODO(), model.AdminCommand{Timestamp: 14, UserID: admin, ItemName: "phone_2"}))
+	s.ChangedOrders()
+
+	// nothing expires before the close time of phone_3
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 25))
+	assert.Empty(t, s.ChangedOrders())
+	assert.Equal(t, model.OrderStatusInit, s.getOrder("phone_1").Status)
+
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 26))
+	changes := s.ChangedOrders()
+	require.Len(t, changes, 1)
+	assert.Equal(t, "phone_3", changes[0].Order.Item.Name)
+	assert.Equal(t, model.OrderStatusSold, changes[0].Order.Status)
+
+	// the reopened order is closed at the new close time, closed orders are not touched again
+	assert.NoError(t, s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 27, UserID: admin, ItemName: "phone_2", CloseTime: 40}))
+	s.ChangedOrders()
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 31))
+	changes = s.ChangedOrders()
+	require.Len(t, changes, 1)
+	assert.Equal(t, "phone_1", changes[0].Order.Item.Name)
+	assert.Equal(t, model.OrderStatusUnsold, changes[0].Order.Status)
+
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	changes = s.ChangedOrders()
+	require.Len(t, changes, 1)
+	assert.Equal(t, "phone_2", changes[0].Order.Item.Name)
+	assertQueuesEmpty(t, s)
+}
+
+func TestStorage_FinishExpiredOrders(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+
+	s := New(WithMaxWinsPerBidder(1))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	bids := []model.BidCommand{
+		{Timestamp: 13, UserID: 3, ItemName: "phone_1", BidAmount: 3000},
+		{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 3000},
+		{Timestamp: 13, UserID: 4, ItemName: "phone_2", BidAmount: 2500},
+	}
+	for _, bid := range bids {
+		assert.NoError(t, s.BidOrder(context.TODO(), bid))
+	}
+
+	// given orders are closed in the close order, phone_3 is not expired yet
+	assert.NoError(t, s.FinishExpiredOrders(context.TODO(), []string{"phone_3", "phone_2", "phone_1", "phone_4"}, 21))
+	assert.Equal(t, model.OrderStatusSold, s.getOrder("phone_1").Status)
+	assert.Equal(t, 3, s.getOrder("phone_1").WinnerID)
+	assert.Equal(t, model.OrderStatusSold, s.getOrder("phone_2").Status)
+	assert.Equal(t, 4, s.getOrder("phone_2").WinnerID)
+	assert.Equal(t, model.OrderStatusInit, s.getOrder("phone_3").Status)
+}
+
+// failingLedger fails recording the given number of next events
+type failingLedger struct {
+	failures int
+}
+
+func (l *failingLedger) Record(model.LedgerEvent) error {
+	if l.failures > 0 {
+		l.failures--
+		return errors.New("ledger is not available")
+	}
+
+	return nil
+}
+
+func TestStorage_CloseQueueError(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New(WithLedger(&failingLedger{failures: 1}))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+
+	// the deadline of the order failed to close is not lost, the next order stays in the queue too
+	assert.Error(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	assert.Equal(t, deadline{time: 15, creationTime: 10, name: "phone_1"}, s.shardOf("phone_1").closeQueue[0])
+	assert.Equal(t, model.OrderStatusInit, s.getOrder("phone_2").Status)
+
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 21))
+	assert.Equal(t, model.OrderStatusUnsold, s.getOrder("phone_2").Status)
+	assertQueuesEmpty(t, s)
+}
+
+func TestStorage_RestoreQueues(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New(WithPaymentDeadline(10))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+
+	restored := New(WithPaymentDeadline(10))
+	assert.NoError(t, restored.Restore(s.Snapshot()))
+
+	// the offer of phone_1 expires at 25, phone_2 closes at 20
+	assert.NoError(t, restored.FinishExpiredAuctions(context.TODO(), 26))
+	assert.Equal(t, model.SettlementStatusDefaulted, restored.getOrder("phone_1").SettlementStatus)
+	assert.Equal(t, model.OrderStatusUnsold, restored.getOrder("phone_2").Status)
+	assertQueuesEmpty(t, restored)
+}
+
+// assertQueuesEmpty checks no deadlines are left in shards
+func assertQueuesEmpty(t *testing.T, s *Storage) {
+	for _, sh := range s.shards {
+		assert.Empty(t, sh.closeQueue)
+		assert.Empty(t, sh.offerQueue)
+	}
+}
+
+func BenchmarkStorage_FinishExpiredAuctions(b *testing.B) {
+	const listings = 1_000_000
+
+	s := New()
+	for _, order := range storagetest.GenerateOrders(listings) {
+		if err := s.CreateOrder(context.TODO(), order); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("nothing_expired", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := s.FinishExpiredAuctions(context.TODO(), 10); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("one_expired", func(b *testing.B) {
+		// close times of generated orders are 5 seconds apart, every heartbeat closes the next order
+		timestamp := int64(10)
+		for i := 0; i < b.N; i++ {
+			timestamp += 5
+			if err := s.FinishExpiredAuctions(context.TODO(), timestamp+1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
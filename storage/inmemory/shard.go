@@ -0,0 +1,125 @@
+package inmemory
+
+import (
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// defaultShards is the number of shards orders are spread across
+const defaultShards = 32
+
+// shard holds orders of items hashed to it. Operations on one order lock its shard only,
+// operations on many orders lock all shards in the shard order
+type shard struct {
+	mx sync.Mutex
+
+	orders         map[string]*model.Order         // imitate order table, key - order name
+	auctionHistory map[string][]*model.OrderAction // imitate auction_history, key - order name, value - array of auction states
+	statistics     map[string]bidStatistics        // statistics of active bids, key - order name
+
+	changed map[string]struct{} // orders changed since the last ChangedOrders call, key - order name
+
+	closeQueue deadlineQueue // close times of opened orders
+	offerQueue deadlineQueue // payment deadlines of pending offers
+
+	summaries    map[string]model.ActionResult // results of archived orders, key - order name
+	touched      map[string]struct{}           // orders changed since the last archiving, key - order name
+	archiveAt    map[string]int64              // archiving times of closed orders, key - order name
+	archiveQueue deadlineQueue                 // archiving times of closed orders
+}
+
+func newShard() *shard {
+	sh := &shard{}
+	sh.reset()
+
+	return sh
+}
+
+// reset removes all orders of the shard
+func (sh *shard) reset() {
+	sh.orders = make(map[string]*model.Order)
+	sh.auctionHistory = make(map[string][]*model.OrderAction)
+	sh.statistics = make(map[string]bidStatistics)
+	sh.changed = make(map[string]struct{})
+	sh.closeQueue = nil
+	sh.offerQueue = nil
+	sh.summaries = make(map[string]model.ActionResult)
+	sh.touched = make(map[string]struct{})
+	sh.archiveAt = make(map[string]int64)
+	sh.archiveQueue = nil
+}
+
+// WithShards sets the number of shards orders are spread across, 1 serializes all operations
+func WithShards(num int) Option {
+	return func(s *Storage) {
+		s.shardCount = max(num, 1)
+	}
+}
+
+// shardOf returns the shard of the order by the FNV-1a hash of its name
+func (s *Storage) shardOf(orderName string) *shard {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	hash := uint32(offset32)
+	for idx := 0; idx < len(orderName); idx++ {
+		hash ^= uint32(orderName[idx])
+		hash *= prime32
+	}
+
+	return s.shards[hash%uint32(len(s.shards))]
+}
+
+// lockShard locks and returns the shard of the order
+func (s *Storage) lockShard(orderName string) *shard {
+	sh := s.shardOf(orderName)
+	sh.mx.Lock()
+
+	return sh
+}
+
+// lockAll locks all shards in the shard order
+func (s *Storage) lockAll() {
+	for _, sh := range s.shards {
+		sh.mx.Lock()
+	}
+}
+
+// unlockAll unlocks all shards
+func (s *Storage) unlockAll() {
+	for _, sh := range s.shards {
+		sh.mx.Unlock()
+	}
+}
+
+// getOrder returns the order or nil if it's not found. The shard of the order must be locked
+func (s *Storage) getOrder(orderName string) *model.Order {
+	return s.shardOf(orderName).orders[orderName]
+}
+
+// getHistory returns the auction history of the order. The shard of the order must be locked
+func (s *Storage) getHistory(orderName string) []*model.OrderAction {
+	return s.shardOf(orderName).auctionHistory[orderName]
+}
+
+// popDue removes and returns the earliest due deadline among queues of all shards. All shards must be locked
+func (s *Storage) popDue(queueOf func(sh *shard) *deadlineQueue, due func(time int64) bool) (deadline, bool) {
+	var next *deadlineQueue
+	for _, sh := range s.shards {
+		queue := queueOf(sh)
+		if len(*queue) == 0 {
+			continue
+		}
+		if next == nil || (*queue)[0].before((*next)[0]) {
+			next = queue
+		}
+	}
+	if next == nil {
+		return deadline{}, false
+	}
+
+	return next.popDue(due)
+}
@@ -0,0 +1,161 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+func TestStorage_ShardOf(t *testing.T) {
+	s := New(WithShards(4))
+	assert.Len(t, s.shards, 4)
+	assert.Same(t, s.shardOf("phone_1"), s.shardOf("phone_1"))
+
+	// 0 shards fall back to the single shard
+	assert.Len(t, New(WithShards(0)).shards, 1)
+}
+
+// TestStorage_ConcurrentBids runs with -race: bidders of the limited credit bid on all items concurrently with heartbeats
+func TestStorage_ConcurrentBids(t *testing.T) {
+	const (
+		items   = 64
+		users   = 8
+		bidders = 16
+		bids    = 500
+		limit   = model.Money(10000)
+	)
+
+	limits := make(testCreditLimits, users)
+	for userID := 1; userID <= users; userID++ {
+		limits[userID] = limit
+	}
+
+	s := New(WithCreditLimits(limits))
+	for idx := 0; idx < items; idx++ {
+		require.NoError(t, s.CreateOrder(context.TODO(), model.Order{
+			Item:         model.Item{Name: fmt.Sprintf("phone_%d", idx+1), ReservePrice: 2000},
+			CreationTime: 10,
+			Status:       model.OrderStatusInit,
+			CloseTime:    1000,
+		}))
+	}
+
+	var wg sync.WaitGroup
+	for bidder := 0; bidder < bidders; bidder++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+
+			rnd := rand.New(rand.NewSource(seed))
+			for idx := 0; idx < bids; idx++ {
+				err := s.BidOrder(context.TODO(), model.BidCommand{
+					Timestamp: 100,
+					UserID:    rnd.Intn(users) + 1,
+					ItemName:  fmt.Sprintf("phone_%d", rnd.Intn(items)+1),
+					BidAmount: model.Money(1000 + rnd.Intn(4000)),
+				})
+				if err != nil {
+					assert.ErrorIs(t, err, model.ErrCreditLimitExceeded)
+				}
+			}
+		}(int64(bidder))
+	}
+
+	// heartbeats, persistence and reports see the consistent view while bids are placed
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for idx := 0; idx < bids; idx++ {
+			assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 100))
+			s.ChangedOrders()
+			_, err := s.GetAuctionResults(context.TODO())
+			assert.NoError(t, err)
+			_, err = s.GetAuctionStatistics(context.TODO(), "phone_1")
+			assert.NoError(t, err)
+		}
+	}()
+	wg.Wait()
+
+	// exposures of users match leading bids and never exceed limits
+	held := make(map[int]model.Money, users)
+	for _, state := range s.Snapshot() {
+		statistics := s.getStatistics(state.Order.Item.Name)
+		assert.Equal(t, countBids(s.getHistory(state.Order.Item.Name)), statistics)
+
+		leader := statistics.leader
+		leaderExposure, ok := s.leaderExposure[state.Order.Item.Name]
+		if leader == nil {
+			assert.False(t, ok)
+			continue
+		}
+		assert.Equal(t, exposure{userID: leader.UserID, amount: leader.BidValue}, leaderExposure)
+		assert.Equal(t, state.Order.LastBid, leader.BidValue)
+		held[leader.UserID] += leader.BidValue
+	}
+	for userID := 1; userID <= users; userID++ {
+		assert.Equal(t, held[userID], s.userExposure[userID])
+		assert.LessOrEqual(t, s.userExposure[userID], limit)
+	}
+
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, results, items)
+}
+
+// BenchmarkStorage_BidOrder compares parallel bidding on different items with the single lock and with shards
+func BenchmarkStorage_BidOrder(b *testing.B) {
+	const items = 1024
+
+	for _, shards := range []int{1, defaultShards} {
+		b.Run(fmt.Sprintf("shards_%d", shards), func(b *testing.B) {
+			s := New(WithShards(shards))
+			for idx := 0; idx < items; idx++ {
+				err := s.CreateOrder(context.TODO(), model.Order{
+					Item:         model.Item{Name: fmt.Sprintf("phone_%d", idx+1)},
+					CreationTime: 10,
+					Status:       model.OrderStatusInit,
+					CloseTime:    1000,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			var next sync.Mutex
+			var bidder int
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				next.Lock()
+				bidder++
+				userID := bidder
+				next.Unlock()
+
+				rnd := rand.New(rand.NewSource(int64(userID)))
+				amount := model.Money(0)
+				for pb.Next() {
+					amount++
+					err := s.BidOrder(context.TODO(), model.BidCommand{
+						Timestamp: 100,
+						UserID:    userID,
+						ItemName:  fmt.Sprintf("phone_%d", rnd.Intn(items)+1),
+						BidAmount: amount,
+					})
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
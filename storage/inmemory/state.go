@@ -0,0 +1,200 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// OrderState is the order with its auction history and result, the unit of saving and restoring the storage state
+type OrderState struct {
+	Order   model.Order
+	History []model.OrderAction // actions in the order they happened, the Order field of actions is not set
+	Result  model.ActionResult
+}
+
+// WithChangeTracking keeps names of changed orders to be returned by ChangedOrders and holds ledger events
+// to be returned by ChangedLedgerEvents, used by persistent storages
+func WithChangeTracking() Option {
+	return func(s *Storage) {
+		s.trackChanges = true
+	}
+}
+
+// ChangedOrders returns states of orders changed since the previous call sorted by order name
+func (s *Storage) ChangedOrders() []OrderState {
+	s.lockAll()
+	defer s.unlockAll()
+
+	var res []OrderState
+	for _, sh := range s.shards {
+		for orderName := range sh.changed {
+			res = append(res, s.getOrderState(sh.orders[orderName]))
+		}
+		sh.changed = make(map[string]struct{})
+	}
+
+	sortStates(res)
+
+	return res
+}
+
+// Snapshot returns states of all orders sorted by order name
+func (s *Storage) Snapshot() []OrderState {
+	s.lockAll()
+	defer s.unlockAll()
+
+	var res []OrderState
+	for _, sh := range s.shards {
+		for _, order := range sh.orders {
+			res = append(res, s.getOrderState(order))
+		}
+	}
+
+	sortStates(res)
+
+	return res
+}
+
+// Restore replaces the storage state with the saved orders.
+// Exposures of open orders and wins of buyers are recounted from the orders
+func (s *Storage) Restore(states []OrderState) error {
+	s.lockAll()
+	defer s.unlockAll()
+
+	for _, sh := range s.shards {
+		sh.reset()
+	}
+
+	// ledger events of the replaced state are not saved and never posted
+	s.ChangedLedgerEvents()
+
+	s.usersMx.Lock()
+	s.leaderExposure = make(map[string]exposure)
+	s.userExposure = make(map[int]model.Money)
+	s.wins = make(map[int]int)
+	s.usersMx.Unlock()
+
+	for _, state := range states {
+		order := s.putState(s.shardOf(state.Order.Item.Name), state)
+		s.countWin(0, order.WinnerID)
+		s.scheduleClose(order)
+		s.scheduleOffer(order)
+	}
+
+	for _, sh := range s.shards {
+		for _, order := range sh.orders {
+			if err := s.refreshExposure(order); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.restoreSummaries(context.Background())
+}
+
+// GetResult provides the auction result of the order computed from its auction history
+func GetResult(state OrderState) model.ActionResult {
+	history := make([]*model.OrderAction, 0, len(state.History))
+	for idx := range state.History {
+		history = append(history, &state.History[idx])
+	}
+
+	return getOrderResult(&state.Order, countBids(history))
+}
+
+// AppendAction appends the action to the saved auction history. Retract and bids drop actions retract and drop
+// bids of their user the same way the storage does, so the history is rebuilt from appended actions alone
+func AppendAction(history []model.OrderAction, action model.OrderAction) []model.OrderAction {
+	action.Order = nil
+	action.Retracted = false
+	action.Dropped = false
+	history = append(history, action)
+
+	switch action.Type {
+	case model.OrderActionTypeRetract:
+		// the latest active bid of the user is retracted
+		for idx := len(history) - 1; idx >= 0; idx-- {
+			if isActiveBidOf(&history[idx], action.UserID) {
+				history[idx].Retracted = true
+				break
+			}
+		}
+	case model.OrderActionTypeBidsDrop:
+		for idx := range history {
+			if isActiveBidOf(&history[idx], action.UserID) {
+				history[idx].Dropped = true
+			}
+		}
+	}
+
+	return history
+}
+
+// isActiveBidOf checks the action is the bid of the user neither retracted nor dropped
+func isActiveBidOf(action *model.OrderAction, userID int) bool {
+	return action.Type == model.OrderActionTypeBid && action.UserID == userID && !action.Retracted && !action.Dropped
+}
+
+// markChanged marks the order to be returned by the next ChangedOrders call and to be checked by the next archiving
+func (s *Storage) markChanged(orderName string) {
+	sh := s.shardOf(orderName)
+	if s.trackChanges {
+		sh.changed[orderName] = struct{}{}
+	}
+	if s.archive != nil {
+		sh.touched[orderName] = struct{}{}
+	}
+}
+
+// putState puts the copy of the saved order to its shard and returns the order. The shard of the order must be locked
+func (s *Storage) putState(sh *shard, state OrderState) *model.Order {
+	order := copyOrder(state.Order)
+	history := make([]*model.OrderAction, 0, len(state.History))
+	for idx := range state.History {
+		action := state.History[idx]
+		action.Order = &order
+		history = append(history, &action)
+	}
+
+	sh.orders[order.Item.Name] = &order
+	sh.auctionHistory[order.Item.Name] = history
+	sh.statistics[order.Item.Name] = countBids(history)
+	if s.archive != nil {
+		sh.touched[order.Item.Name] = struct{}{}
+	}
+
+	return &order
+}
+
+// getOrderState returns the copy of the order with its auction history and result
+func (s *Storage) getOrderState(order *model.Order) OrderState {
+	history := make([]model.OrderAction, 0, len(s.getHistory(order.Item.Name)))
+	for _, action := range s.getHistory(order.Item.Name) {
+		copied := *action
+		copied.Order = nil
+		history = append(history, copied)
+	}
+
+	return OrderState{
+		Order:   copyOrder(*order),
+		History: history,
+		Result:  getOrderResult(order, s.getStatistics(order.Item.Name)),
+	}
+}
+
+// sortStates sorts states by order name
+func sortStates(states []OrderState) {
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Order.Item.Name < states[j].Order.Item.Name
+	})
+}
+
+// copyOrder returns the order not sharing offers and deposits with the original one
+func copyOrder(order model.Order) model.Order {
+	order.Offers = append([]model.Offer(nil), order.Offers...)
+	order.Deposits = append([]model.Deposit(nil), order.Deposits...)
+
+	return order
+}
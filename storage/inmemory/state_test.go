@@ -0,0 +1,115 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_ChangedOrders(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New(WithChangeTracking())
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[1]))
+	assert.Len(t, s.ChangedOrders(), 2)
+	assert.Empty(t, s.ChangedOrders())
+
+	// rejected bids change nothing
+	err := s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 30, UserID: 3, ItemName: "phone_1", BidAmount: 2500})
+	assert.ErrorIs(t, err, model.ErrAuctionIsFinishedByTime)
+	assert.Empty(t, s.ChangedOrders())
+
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	changes := s.ChangedOrders()
+	assert.Len(t, changes, 1)
+	assert.Equal(t, model.Money(2500), changes[0].Order.LastBid)
+	assert.Len(t, changes[0].History, 2)
+	assert.Nil(t, changes[0].History[1].Order)
+	assert.Equal(t, 1, changes[0].Result.Statistics.TotalBidCount)
+
+	// closing by time changes the order without a history action
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	changes = s.ChangedOrders()
+	assert.Len(t, changes, 1)
+	assert.Equal(t, model.OrderStatusSold, changes[0].Order.Status)
+}
+
+func TestStorage_Restore(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+
+	s := New(WithMaxWinsPerBidder(2), WithChangeTracking())
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: order.Item.Name, BidAmount: 2500}))
+	}
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 14, UserID: 3, ItemName: "phone_3"}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+
+	restored := New(WithMaxWinsPerBidder(2))
+	assert.NoError(t, restored.Restore(s.ChangedOrders()))
+	assert.Equal(t, s.Snapshot(), restored.Snapshot())
+	assert.Equal(t, s.wins, restored.wins)
+	assert.Equal(t, s.leaderExposure, restored.leaderExposure)
+	assert.Equal(t, s.userExposure, restored.userExposure)
+	assert.Empty(t, restored.ChangedOrders())
+
+	// actions refer to the restored order
+	history := restored.getHistory("phone_2")
+	assert.Same(t, restored.getOrder("phone_2"), history[len(history)-1].Order)
+	assert.True(t, restored.getHistory("phone_3")[1].Retracted)
+
+	expRes, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	results, err := restored.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, expRes, results)
+}
+
+func TestStorage_Snapshot(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New(WithChangeTracking())
+	assert.Empty(t, s.Snapshot())
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[1]))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_2", BidAmount: 2500}))
+
+	// the snapshot doesn't reset changes
+	snapshot := s.Snapshot()
+	assert.Equal(t, s.ChangedOrders(), snapshot)
+	assert.Equal(t, "phone_1", snapshot[0].Order.Item.Name)
+	assert.Len(t, snapshot[1].History, 2)
+
+	// the snapshot doesn't share offers with the storage
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Empty(t, snapshot[1].Order.Offers)
+	assert.Equal(t, model.OrderStatusInit, snapshot[1].Order.Status)
+}
+
+func TestAppendAction(t *testing.T) {
+	actions := []model.OrderAction{
+		{Type: model.OrderActionTypeInit, Timestamp: 10},
+		{Type: model.OrderActionTypeBid, Timestamp: 11, UserID: 3, BidValue: 2500},
+		{Type: model.OrderActionTypeBid, Timestamp: 12, UserID: 3, BidValue: 3000},
+		{Type: model.OrderActionTypeBid, Timestamp: 12, UserID: 4, BidValue: 2800, Retracted: true},
+		{Type: model.OrderActionTypeRetract, Timestamp: 13, UserID: 3},
+		{Type: model.OrderActionTypeBidsDrop, Timestamp: 15, UserID: 3},
+		{Type: model.OrderActionTypeRetract, Timestamp: 16, UserID: 3},
+	}
+
+	// flags of appended actions are set by retract and bids drop actions only, dropped bids are not retracted
+	var history []model.OrderAction
+	for _, action := range actions {
+		history = AppendAction(history, action)
+	}
+	assert.Len(t, history, len(actions))
+	assert.Equal(t, []bool{false, false, true, false}, []bool{history[0].Retracted, history[1].Retracted, history[2].Retracted, history[3].Retracted})
+	assert.True(t, history[1].Dropped)
+	assert.False(t, history[2].Dropped)
+	assert.Equal(t, model.AuctionStatistics{TotalBidCount: 2, HighestBid: 2800, LowestBid: 2500}, CountBids(history))
+}
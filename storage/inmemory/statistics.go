@@ -0,0 +1,111 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/senseyman/auction-house/model"
+)
+
+// bidStatistics is the statistics of valid bids of the order. It's updated on every accepted bid
+// and recounted from the auction history when bids are retracted or dropped. Dropped bids of bidders
+// who have won the max number of items are counted, but can't lead
+type bidStatistics struct {
+	count   int
+	lowest  model.Money
+	highest model.Money
+	leader  *model.OrderAction // the earliest of the highest active bids
+}
+
+// countBids counts the statistics of valid bids of the auction history
+func countBids(auctionHistory []*model.OrderAction) bidStatistics {
+	return countBidsWithout(auctionHistory, nil)
+}
+
+// countBidsWithout counts the statistics of valid bids of the auction history except the excluded bid
+func countBidsWithout(auctionHistory []*model.OrderAction, excluded *model.OrderAction) bidStatistics {
+	var st bidStatistics
+	for _, action := range auctionHistory {
+		if action != excluded {
+			st.add(action)
+		}
+	}
+
+	return st
+}
+
+// CountBids provides statistics of valid bids of the saved auction history the same way as for auction results
+func CountBids(auctionHistory []model.OrderAction) model.AuctionStatistics {
+	var st bidStatistics
+	for idx := range auctionHistory {
+		st.add(&auctionHistory[idx])
+	}
+
+	return st.result()
+}
+
+// add counts the action if it's the valid bid
+func (st *bidStatistics) add(action *model.OrderAction) {
+	if action.Type != model.OrderActionTypeBid || action.Retracted {
+		return
+	}
+
+	if st.count == 0 || action.BidValue < st.lowest {
+		st.lowest = action.BidValue
+	}
+	if action.BidValue > st.highest {
+		st.highest = action.BidValue
+	}
+	if !action.Dropped && (st.leader == nil || action.BidValue > st.leader.BidValue) {
+		st.leader = action
+	}
+	st.count++
+}
+
+// leading returns the leading bid, 0 if there are no active bids
+func (st bidStatistics) leading() model.Money {
+	if st.leader == nil {
+		return 0
+	}
+
+	return st.leader.BidValue
+}
+
+// result returns the statistics reported in auction results
+func (st bidStatistics) result() model.AuctionStatistics {
+	if st.count == 0 { // has no valid bids
+		return model.AuctionStatistics{}
+	}
+
+	return model.AuctionStatistics{
+		TotalBidCount: st.count,
+		HighestBid:    st.highest,
+		LowestBid:     st.lowest,
+	}
+}
+
+// GetAuctionStatistics provides statistics of valid bids of the order at the moment, opened and archived orders included
+func (s *Storage) GetAuctionStatistics(_ context.Context, itemName string) (model.AuctionStatistics, error) {
+	sh := s.lockShard(itemName)
+	defer sh.mx.Unlock()
+
+	if _, ok := sh.orders[itemName]; ok {
+		return sh.statistics[itemName].result(), nil
+	}
+	if summary, ok := sh.summaries[itemName]; ok {
+		return summary.Statistics, nil
+	}
+
+	return model.AuctionStatistics{}, model.ErrNotFound
+}
+
+// getStatistics returns the statistics of valid bids of the order. The shard of the order must be locked
+func (s *Storage) getStatistics(orderName string) bidStatistics {
+	return s.shardOf(orderName).statistics[orderName]
+}
+
+// recountStatistics recounts the statistics of the order after its bids are retracted or dropped.
+// The shard of the order must be locked
+func (s *Storage) recountStatistics(orderName string) {
+	sh := s.shardOf(orderName)
+	sh.statistics[orderName] = countBids(sh.auctionHistory[orderName])
+}
@@ -0,0 +1,67 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_GetAuctionStatistics(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New(WithMaxWinsPerBidder(1))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+
+	_, err := s.GetAuctionStatistics(context.TODO(), "tv_1")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	statistics, err := s.GetAuctionStatistics(context.TODO(), "phone_2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuctionStatistics{}, statistics)
+
+	bids := []model.BidCommand{
+		{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500},
+		{Timestamp: 12, UserID: 3, ItemName: "phone_2", BidAmount: 3000},
+		{Timestamp: 13, UserID: 4, ItemName: "phone_2", BidAmount: 2200},
+		{Timestamp: 13, UserID: 5, ItemName: "phone_2", BidAmount: 3000},
+		{Timestamp: 14, UserID: 5, ItemName: "phone_2", BidAmount: 1500},
+	}
+	for _, bid := range bids {
+		assert.NoError(t, s.BidOrder(context.TODO(), bid))
+	}
+
+	// statistics of the opened auction are updated on every bid, the earliest of the highest bids leads
+	statistics, err = s.GetAuctionStatistics(context.TODO(), "phone_2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuctionStatistics{TotalBidCount: 4, HighestBid: 3000, LowestBid: 1500}, statistics)
+	assert.Equal(t, 3, s.getStatistics("phone_2").leader.UserID)
+
+	// retracted bids are not counted
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 15, UserID: 5, ItemName: "phone_2"}))
+	statistics, err = s.GetAuctionStatistics(context.TODO(), "phone_2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuctionStatistics{TotalBidCount: 3, HighestBid: 3000, LowestBid: 2200}, statistics)
+
+	// user 3 wins phone_1, their bids on phone_2 are dropped, but still counted
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	statistics, err = s.GetAuctionStatistics(context.TODO(), "phone_2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.AuctionStatistics{TotalBidCount: 3, HighestBid: 3000, LowestBid: 2200}, statistics)
+	assert.Equal(t, 5, s.getStatistics("phone_2").leader.UserID)
+	assert.Equal(t, model.Money(3000), s.getOrder("phone_2").LastBid)
+
+	// statistics in results are the same as counted from the history
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	for _, result := range results {
+		assert.Equal(t, countBids(s.getHistory(result.Item)).result(), result.Statistics)
+	}
+	assert.Equal(t, 5, results[1].UserID)
+}
@@ -2,160 +2,628 @@ package inmemory
 
 import (
 	"context"
-	"math"
 	"sort"
 	"sync"
 
 	"github.com/senseyman/auction-house/model"
 )
 
+// defaultRetractionWindow is the number of seconds a bidder has to retract the latest bid
+const defaultRetractionWindow int64 = 10
+
 // Storage emulates in-memory storage for storing and processing auction data.
+// Orders are spread across shards locked separately, so independent auctions are processed concurrently.
 type Storage struct {
-	mx sync.Mutex
+	shards     []*shard
+	shardCount int
+
+	usersMx        sync.Mutex          // guards exposures and wins shared by orders of all shards
+	leaderExposure map[string]exposure // leading bids of open orders, key - order name
+	userExposure   map[int]model.Money // sum of leading bids of a user in the reporting currency, key - user id
+	wins           map[int]int         // number of items sold to a user, key - user id
+
+	retractionWindow int64
+	converter        CurrencyConverter
+	feeCalculator    FeeCalculator
+	creditLimits     CreditLimits
+	paymentDeadline  int64
+	ledger           Ledger
+	maxWins          int
+	trackChanges     bool
+	archive          Archive
+	archiveAfter     int64
+
+	ledgerMx     sync.Mutex          // guards ledger events held by change tracking
+	ledgerEvents []model.LedgerEvent // ledger events not saved yet, kept only with change tracking
+}
+
+// FeeCalculator computes fees the auction house earns on the close bid
+type FeeCalculator interface {
+	BuyerPremium(hammerPrice model.Money) model.Money
+	SellerCommission(hammerPrice model.Money) model.Money
+}
+
+// CurrencyConverter converts bids to the currency of the order
+type CurrencyConverter interface {
+	Convert(amount model.Money, from, to model.Currency, timestamp int64) (model.Money, error)
+}
+
+// Option configures auction policies of the storage
+type Option func(s *Storage)
+
+// WithRetractionWindow sets the number of seconds a bidder has to retract the latest bid
+func WithRetractionWindow(seconds int64) Option {
+	return func(s *Storage) {
+		s.retractionWindow = seconds
+	}
+}
+
+// WithCurrencyConverter sets the converter for orders and bids in different currencies
+func WithCurrencyConverter(converter CurrencyConverter) Option {
+	return func(s *Storage) {
+		s.converter = converter
+	}
+}
 
-	orders         map[string]*model.Order         // imitate order table, key - order name
-	auctionHistory map[string][]*model.OrderAction // imitate auction_history, key - order name, value - array of auction states
+// WithFeeCalculator sets the calculator of fees computed on closing sold orders
+func WithFeeCalculator(feeCalculator FeeCalculator) Option {
+	return func(s *Storage) {
+		s.feeCalculator = feeCalculator
+	}
 }
 
-func New() *Storage {
-	return &Storage{
-		orders:         make(map[string]*model.Order),
-		auctionHistory: make(map[string][]*model.OrderAction),
+func New(opts ...Option) *Storage {
+	s := &Storage{
+		shardCount:       defaultShards,
+		leaderExposure:   make(map[string]exposure),
+		userExposure:     make(map[int]model.Money),
+		wins:             make(map[int]int),
+		retractionWindow: defaultRetractionWindow,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.shards = make([]*shard, s.shardCount)
+	for idx := range s.shards {
+		s.shards[idx] = newShard()
 	}
+
+	return s
 }
 
 // CreateOrder method stores order and initiate first auction state
 func (s *Storage) CreateOrder(_ context.Context, order model.Order) error {
-	s.mx.Lock()
-	defer s.mx.Unlock()
+	sh := s.lockShard(order.Item.Name)
+	defer sh.mx.Unlock()
 
-	s.orders[order.Item.Name] = &order
+	// check the currency of the order can be converted since the auction creation
+	if _, err := s.convert(0, order.Item.Currency, "", order.CreationTime); err != nil {
+		return err
+	}
 
-	auctionHistory := s.auctionHistory[order.Item.Name]
-	auctionHistory = append(auctionHistory, &model.OrderAction{
-		Order:    &order,
-		UserID:   0,
-		BidValue: 0,
+	order.OriginalReservePrice = order.Item.ReservePrice
+	sh.orders[order.Item.Name] = &order
+	delete(sh.summaries, order.Item.Name)
+	s.scheduleClose(&order)
+
+	s.appendAction(&model.OrderAction{
+		Order:        &order,
+		Type:         model.OrderActionTypeInit,
+		Timestamp:    order.CreationTime,
+		UserID:       0,
+		BidValue:     0,
+		ReservePrice: order.Item.ReservePrice,
+		CloseTime:    order.CloseTime,
 	})
-	s.auctionHistory[order.Item.Name] = auctionHistory
 
 	return nil
 }
 
 // BidOrder method checks bid value for the order and saves the bid to the history data
-func (s *Storage) BidOrder(_ context.Context, bid model.BidCommand) error {
-	s.mx.Lock()
-	defer s.mx.Unlock()
+func (s *Storage) BidOrder(ctx context.Context, bid model.BidCommand) error {
+	sh := s.lockShard(bid.ItemName)
+	defer sh.mx.Unlock()
 
 	// find order
-	order, ok := s.orders[bid.ItemName]
-	if !ok {
-		return model.ErrNotFound
+	order, err := s.loadOrder(ctx, sh, bid.ItemName)
+	if err != nil {
+		return err
 	}
 
-	// check timestamp
-	if bid.Timestamp > order.CloseTime {
-		return model.ErrAuctionIsFinishedByTime
+	// check the auction is still opened
+	if err := checkOrderIsOpen(order, bid.Timestamp); err != nil {
+		return err
+	}
+
+	// check the auction is already started
+	if bid.Timestamp < order.StartTime {
+		return model.ErrAuctionIsNotStarted
+	}
+
+	// check the bidder can win one more item
+	if s.hasMaxWins(bid.UserID) {
+		return model.ErrMaxWinsReached
+	}
+
+	// check the bidder is pre-qualified by the deposit
+	if err := checkDeposit(order, bid.UserID); err != nil {
+		return err
+	}
+
+	// convert the bid to the currency of the order for comparison
+	bidAmount, err := s.convert(bid.BidAmount, bid.Currency, order.Item.Currency, bid.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	// check the bid is not lower than the starting price
+	if bidAmount < order.Item.StartPrice {
+		return model.ErrBidIsTooLow
+	}
+
+	// check the credit limit of the bidder if the bid takes the lead, the previous leader is outbid.
+	// The check and the new exposure are done at once as bids in other shards change exposures concurrently
+	if bidAmount > order.LastBid || s.getStatistics(bid.ItemName).leader == nil {
+		reportingAmount, err := s.convert(bidAmount, order.Item.Currency, "", bid.Timestamp)
+		if err != nil {
+			return err
+		}
+		if err = s.takeLead(bid.ItemName, bid.UserID, reportingAmount); err != nil {
+			return err
+		}
 	}
 
 	// check bid price
-	if bid.BidAmount > order.LastBid {
+	if bidAmount > order.LastBid {
 		// update new bid amount
-		order.LastBid = bid.BidAmount
+		order.LastBid = bidAmount
 	}
 	// update order
-	s.orders[bid.ItemName] = order
+	sh.orders[bid.ItemName] = order
 
 	// update history
-	auctionHistory := s.auctionHistory[bid.ItemName]
-	auctionHistory = append(auctionHistory, &model.OrderAction{
-		Order:    order,
-		UserID:   bid.UserID,
-		BidValue: bid.BidAmount,
+	s.appendAction(&model.OrderAction{
+		Order:     order,
+		Type:      model.OrderActionTypeBid,
+		Timestamp: bid.Timestamp,
+		UserID:    bid.UserID,
+		BidValue:  bidAmount,
+
+		NativeBidValue: bid.BidAmount,
+		Currency:       bid.Currency,
 	})
-	s.auctionHistory[bid.ItemName] = auctionHistory
 
 	return nil
 }
 
-// FinishExpiredAuctions method finishes auctions that expired by time
-func (s *Storage) FinishExpiredAuctions(_ context.Context, timestamp int64) error {
-	s.mx.Lock()
-	defer s.mx.Unlock()
-
-	for orderName := range s.orders {
-		order := s.orders[orderName]
-		if timestamp > order.CloseTime {
-			s.closeOrder(order)
-			s.orders[orderName] = order
+// CancelOrder method withdraws the order by its seller while the reserve price is not met
+func (s *Storage) CancelOrder(ctx context.Context, cancel model.CancelCommand) error {
+	sh := s.lockShard(cancel.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, cancel.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if order.SellerID != cancel.UserID {
+		return model.ErrNotSeller
+	}
+
+	if err := checkOrderIsOpen(order, cancel.Timestamp); err != nil {
+		return err
+	}
+
+	// the reserve price is met by bids, the item without the reserve price may be withdrawn until the first bid
+	if s.getStatistics(cancel.ItemName).leader != nil && isReserveMet(order) {
+		return model.ErrReserveIsMet
+	}
+
+	order.Status = model.OrderStatusCancelled
+	order.CloseTime = cancel.Timestamp
+	sh.orders[cancel.ItemName] = order
+	s.releaseExposure(cancel.ItemName)
+	refundDeposits(order, cancel.Timestamp, 0)
+
+	s.addAuditAction(order, model.OrderActionTypeCancel, cancel.Timestamp, cancel.UserID)
+
+	return nil
+}
+
+// RetractBid method withdraws the latest bid of the user and recomputes the leading bid of the order
+func (s *Storage) RetractBid(ctx context.Context, retract model.RetractCommand) error {
+	sh := s.lockShard(retract.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, retract.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOrderIsOpen(order, retract.Timestamp); err != nil {
+		return err
+	}
+
+	auctionHistory := s.getHistory(retract.ItemName)
+
+	// find the latest active bid of the user, dropped bids can't be retracted
+	var bid *model.OrderAction
+	for idx := len(auctionHistory) - 1; idx >= 0; idx-- {
+		action := auctionHistory[idx]
+		if action.Type == model.OrderActionTypeBid && !action.Retracted && !action.Dropped && action.UserID == retract.UserID {
+			bid = action
+			break
 		}
 	}
+	if bid == nil {
+		return model.ErrBidNotFound
+	}
+
+	if retract.Timestamp-bid.Timestamp > s.retractionWindow {
+		return model.ErrRetractionWindowExpired
+	}
+
+	// recompute the leading bid, the order is changed once the exposure of the new leader is known
+	statistics := countBidsWithout(auctionHistory, bid)
+	leader, err := s.getExposure(order, statistics.leader)
+	if err != nil {
+		return err
+	}
+
+	bid.Retracted = true
+	s.appendAction(&model.OrderAction{
+		Order:     order,
+		Type:      model.OrderActionTypeRetract,
+		Timestamp: retract.Timestamp,
+		UserID:    retract.UserID,
+		BidValue:  bid.BidValue,
+	})
+	sh.statistics[retract.ItemName] = statistics
+	order.LastBid = statistics.leading()
+	s.setExposure(retract.ItemName, leader)
 
 	return nil
 }
 
-// FinishAllAuctions method finishes unfinished auctions
-func (s *Storage) FinishAllAuctions(_ context.Context) error {
-	s.mx.Lock()
-	defer s.mx.Unlock()
-
-	for orderName := range s.orders {
-		order := s.orders[orderName]
-		if order.Status == model.OrderStatusInit {
-			// finish order that is still opened
-			s.closeOrder(order)
-			s.orders[orderName] = order
+// LowerReserve method lowers the reserve price of the opened order by its seller
+func (s *Storage) LowerReserve(ctx context.Context, reserve model.ReserveCommand) error {
+	sh := s.lockShard(reserve.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, reserve.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if order.SellerID != reserve.UserID {
+		return model.ErrNotSeller
+	}
+
+	if err := checkOrderIsOpen(order, reserve.Timestamp); err != nil {
+		return err
+	}
+
+	if reserve.ReservePrice < 0 {
+		return model.ErrInvalidData
+	}
+	if reserve.ReservePrice > order.Item.ReservePrice {
+		return model.ErrReserveCanNotBeRaised
+	}
+
+	order.Item.ReservePrice = reserve.ReservePrice
+	s.appendAction(&model.OrderAction{
+		Order:        order,
+		Type:         model.OrderActionTypeReserveChange,
+		Timestamp:    reserve.Timestamp,
+		UserID:       reserve.UserID,
+		ReservePrice: reserve.ReservePrice,
+	})
+
+	return nil
+}
+
+// ExtendAuction method moves the close time of the opened order to the later time
+func (s *Storage) ExtendAuction(ctx context.Context, cmd model.AdminCommand) error {
+	sh := s.lockShard(cmd.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, cmd.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != model.OrderStatusInit {
+		return model.ErrAuctionIsClosed
+	}
+
+	// the auction expired by time is not brought back before the heartbeat closes it, it may be reopened after that
+	if cmd.Timestamp >= order.CloseTime {
+		return model.ErrAuctionIsFinishedByTime
+	}
+
+	if cmd.CloseTime <= order.CloseTime {
+		return model.ErrInvalidData
+	}
+
+	order.CloseTime = cmd.CloseTime
+	s.scheduleClose(order)
+	s.addAuditAction(order, model.OrderActionTypeExtend, cmd.Timestamp, cmd.UserID)
+
+	return nil
+}
+
+// ForceCloseAuction method closes the opened order before its close time
+func (s *Storage) ForceCloseAuction(ctx context.Context, cmd model.AdminCommand) error {
+	s.lockAll()
+	defer s.unlockAll()
+
+	if cmd.CloseTime != 0 {
+		return model.ErrInvalidData
+	}
+
+	order, err := s.loadOrder(ctx, s.shardOf(cmd.ItemName), cmd.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != model.OrderStatusInit {
+		return model.ErrAuctionIsClosed
+	}
+
+	if cmd.Timestamp < order.CloseTime {
+		order.CloseTime = cmd.Timestamp
+	}
+	if err := s.closeOrder(order); err != nil {
+		return err
+	}
+	s.addAuditAction(order, model.OrderActionTypeForceClose, cmd.Timestamp, cmd.UserID)
+
+	return nil
+}
+
+// VoidAuction method invalidates the order. Voided order has no winner and no price
+func (s *Storage) VoidAuction(ctx context.Context, cmd model.AdminCommand) error {
+	if cmd.CloseTime != 0 {
+		return model.ErrInvalidData
+	}
+
+	sh := s.lockShard(cmd.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, cmd.ItemName)
+	if err != nil {
+		return err
+	}
+
+	if order.Status == model.OrderStatusVoided || order.Status == model.OrderStatusCancelled {
+		return model.ErrAuctionIsClosed
+	}
+
+	if isPaid(order) {
+		return model.ErrInvalidSettlementStatus
+	}
+
+	if err := s.reverseSale(order, cmd.Timestamp); err != nil {
+		return err
+	}
+
+	if order.Status == model.OrderStatusInit {
+		if cmd.Timestamp < order.CloseTime {
+			order.CloseTime = cmd.Timestamp
 		}
+		order.ReserveMet = isReserveMet(order)
 	}
+	order.Status = model.OrderStatusVoided
+	refundDeposits(order, cmd.Timestamp, 0)
+	s.setBuyer(order, 0, 0)
+	order.Offers = nil
+	order.SettlementStatus = ""
+	s.releaseExposure(cmd.ItemName)
+	s.addAuditAction(order, model.OrderActionTypeVoid, cmd.Timestamp, cmd.UserID)
 
 	return nil
 }
 
-func (s *Storage) closeOrder(order *model.Order) {
-	// closing order
-	if order.LastBid >= order.Item.ReservePrice {
-		order.Status = model.OrderStatusSold
-		// set the price
-		order.CloseBid = s.getOrderAuctionFinalPrice(order.Item.Name, order.Item.ReservePrice)
-	} else {
+// ReopenAuction method opens the closed order again until the new close time
+func (s *Storage) ReopenAuction(ctx context.Context, cmd model.AdminCommand) error {
+	sh := s.lockShard(cmd.ItemName)
+	defer sh.mx.Unlock()
+
+	order, err := s.loadOrder(ctx, sh, cmd.ItemName)
+	if err != nil {
+		return err
+	}
+
+	switch order.Status {
+	case model.OrderStatusSold, model.OrderStatusUnsold, model.OrderStatusVoided:
+		// auctions closed by time or by admins are reopened
+	case model.OrderStatusInit:
+		return model.ErrAuctionIsOpen
+	default:
+		// the item withdrawn by the seller stays cancelled
+		return model.ErrAuctionIsCancelled
+	}
+
+	if isPaid(order) {
+		return model.ErrInvalidSettlementStatus
+	}
+
+	if cmd.CloseTime < cmd.Timestamp {
+		return model.ErrInvalidData
+	}
+
+	if err := s.reverseSale(order, cmd.Timestamp); err != nil {
+		return err
+	}
+
+	order.Status = model.OrderStatusInit
+	order.CloseTime = cmd.CloseTime
+	s.setBuyer(order, 0, 0)
+	order.Offers = nil
+	order.SettlementStatus = ""
+	order.ReserveMet = false
+	s.scheduleClose(order)
+	s.addAuditAction(order, model.OrderActionTypeReopen, cmd.Timestamp, cmd.UserID)
+
+	return s.refreshExposure(order)
+}
+
+// FinishExpiredAuctions method finishes auctions that expired by time
+func (s *Storage) FinishExpiredAuctions(ctx context.Context, timestamp int64) error {
+	s.lockAll()
+	defer s.unlockAll()
+
+	err := s.closeOrders(func(closeTime int64) bool {
+		return timestamp > closeTime
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.finishExpired(ctx, timestamp)
+}
+
+// FinishExpiredOrders method finishes the given orders that expired by time in the close order, the same way as
+// FinishExpiredAuctions does for all orders. It lets persisted storages find expired orders by their close time index
+func (s *Storage) FinishExpiredOrders(ctx context.Context, names []string, timestamp int64) error {
+	s.lockAll()
+	defer s.unlockAll()
+
+	due := make([]deadline, 0, len(names))
+	for _, name := range names {
+		order := s.getOrder(name)
+		if order == nil || order.Status != model.OrderStatusInit || timestamp <= order.CloseTime {
+			continue
+		}
+		due = append(due, deadline{time: order.CloseTime, creationTime: order.CreationTime, name: name})
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].before(due[j])
+	})
+
+	for _, next := range due {
+		if err := s.closeOrder(s.getOrder(next.name)); err != nil {
+			return err
+		}
+	}
+
+	return s.finishExpired(ctx, timestamp)
+}
+
+// finishExpired expires payment offers and archives orders finished by time. All shards must be locked
+func (s *Storage) finishExpired(ctx context.Context, timestamp int64) error {
+	if err := s.expireOffers(timestamp); err != nil {
+		return err
+	}
+
+	return s.archiveOrders(ctx, timestamp)
+}
+
+// FinishAllAuctions method finishes unfinished auctions
+func (s *Storage) FinishAllAuctions(_ context.Context) error {
+	s.lockAll()
+	defer s.unlockAll()
+
+	// finish orders that are still opened
+	return s.closeOrders(func(int64) bool {
+		return true
+	})
+}
+
+func (s *Storage) closeOrder(order *model.Order) error {
+	s.markChanged(order.Item.Name)
+	s.releaseExposure(order.Item.Name)
+	winner, price := closeAuction(order, s.getHistory(order.Item.Name), s.getStatistics(order.Item.Name))
+	if order.Status == model.OrderStatusUnsold {
+		refundDeposits(order, order.CloseTime, 0)
+
+		return nil
+	}
+	if winner == nil {
+		// there is nobody to pay for the item sold without bids
+		s.setBuyer(order, 0, price)
+		refundDeposits(order, order.CloseTime, 0)
+
+		return nil
+	}
+
+	s.setBuyer(order, winner.UserID, price)
+	// the winner is the first to be offered to pay
+	order.Offers = []model.Offer{s.newOffer(order.WinnerID, order.CloseBid, order.CloseTime)}
+	order.SettlementStatus = model.SettlementStatusAwaitingPayment
+	s.scheduleOffer(order)
+	refundDeposits(order, order.CloseTime, order.WinnerID)
+
+	if err := s.postLedgerEvent(model.LedgerEventSale, order, order.CloseTime); err != nil {
+		return err
+	}
+
+	return s.dropBidsOnMaxWins(order.WinnerID, order.CloseTime)
+}
+
+// closeAuction closes the order with the reserve price in effect at the close time and returns the winning bid
+// and the price. The leading bid reaching the reserve price wins and pays the second highest bid or the reserve price.
+// The item without the reserve price is sold even without bids, the winning bid is nil then. Only the order is changed
+func closeAuction(order *model.Order, auctionHistory []*model.OrderAction, statistics bidStatistics) (*model.OrderAction, model.Money) {
+	order.Item.ReservePrice = getReservePriceAt(auctionHistory, order.CloseTime)
+	order.ReserveMet = isReserveMet(order)
+	if !order.ReserveMet {
 		order.Status = model.OrderStatusUnsold
+		return nil, 0
+	}
+
+	order.Status = model.OrderStatusSold
+	if statistics.leader == nil {
+		return nil, order.Item.ReservePrice
+	}
+
+	return statistics.leader, getFinalPrice(auctionHistory, order.Item.ReservePrice)
+}
+
+// setBuyer sets the buyer of the order, the price and fees computed on the price
+func (s *Storage) setBuyer(order *model.Order, userID int, price model.Money) {
+	s.countWin(order.WinnerID, userID)
+	order.WinnerID = userID
+	order.CloseBid = price
+	order.BuyerPremium = 0
+	order.SellerCommission = 0
+	if s.feeCalculator != nil && price > 0 {
+		order.BuyerPremium = s.feeCalculator.BuyerPremium(price)
+		order.SellerCommission = s.feeCalculator.SellerCommission(price)
 	}
 }
 
-func (s *Storage) getOrderAuctionFinalPrice(orderName string, reservePrice float32) float32 {
-	auctionHistory := s.auctionHistory[orderName]
-	if len(auctionHistory) < 3 {
-		// we have only initial state and maybe first bid - return reserve price
+// getFinalPrice returns the second highest active bid of the auction history, the reserve price if there is only one bid
+func getFinalPrice(auctionHistory []*model.OrderAction, reservePrice model.Money) model.Money {
+	bids := getActiveBids(auctionHistory)
+	if len(bids) < 2 {
+		// we have only one bid - return reserve price
 		return reservePrice
 	}
 
-	return auctionHistory[len(auctionHistory)-2].BidValue // return second last bid
+	sort.SliceStable(bids, func(i, j int) bool {
+		return bids[i].BidValue > bids[j].BidValue
+	})
+
+	return bids[1].BidValue // return second highest bid
 }
 
 // GetAuctionResults provides results of all auctions
 func (s *Storage) GetAuctionResults(_ context.Context) ([]model.ActionResult, error) {
-	results := make([]model.ActionResult, 0, len(s.orders))
+	s.lockAll()
+	defer s.unlockAll()
 
-	// collect all orders to sort it in a time order
-	orders := make([]*model.Order, 0, len(s.orders))
-	for orderName := range s.orders {
-		orders = append(orders, s.orders[orderName])
-	}
+	results := make([]model.ActionResult, 0)
 
-	for _, order := range orders {
-		userID, stat := s.getOrderAuctionStatistics(order.Item.Name)
-		if order.Status == model.OrderStatusUnsold {
-			userID = 0
+	// collect all orders to sort it in a time order
+	for _, sh := range s.shards {
+		for _, order := range sh.orders {
+			results = append(results, getOrderResult(order, sh.statistics[order.Item.Name]))
+		}
+		for _, summary := range sh.summaries {
+			results = append(results, summary)
 		}
-		results = append(results, model.ActionResult{
-			CreationTime: order.CreationTime,
-			CloseTime:    order.CloseTime,
-			Item:         order.Item.Name,
-			UserID:       userID,
-			Status:       order.Status,
-			PricePaid:    order.CloseBid,
-			Statistics:   stat,
-		})
 	}
 
 	sort.Slice(results, func(i, j int) bool {
@@ -165,31 +633,113 @@ func (s *Storage) GetAuctionResults(_ context.Context) ([]model.ActionResult, er
 	return results, nil
 }
 
-func (s *Storage) getOrderAuctionStatistics(orderName string) (int, model.AuctionStatistics) {
-	auctionHistory := s.auctionHistory[orderName]
+// getOrderResult provides the auction result of the order
+func getOrderResult(order *model.Order, statistics bidStatistics) model.ActionResult {
+	return model.ActionResult{
+		CreationTime: order.CreationTime,
+		StartTime:    order.StartTime,
+		CloseTime:    order.CloseTime,
+		Item:         order.Item.Name,
+		Currency:     order.Item.Currency,
+		SellerID:     order.SellerID,
+		UserID:       order.WinnerID,
+		Status:       order.Status,
+		PricePaid:    order.CloseBid,
+		StartPrice:   order.Item.StartPrice,
+		ReserveMet:   order.ReserveMet,
+		Statistics:   statistics.result(),
+
+		OriginalReservePrice: order.OriginalReservePrice,
+		FinalReservePrice:    order.Item.ReservePrice,
+
+		BuyerPremium:     order.BuyerPremium,
+		SellerCommission: order.SellerCommission,
+
+		Offers:           append([]model.Offer(nil), order.Offers...),
+		SettlementStatus: order.SettlementStatus,
+		Deposits:         append([]model.Deposit(nil), order.Deposits...),
+	}
+}
 
-	if len(auctionHistory) < 2 { // has only init state
-		return 0, model.AuctionStatistics{}
+// convert converts the amount between currencies. Storage without converter supports the reporting currency only
+func (s *Storage) convert(amount model.Money, from, to model.Currency, timestamp int64) (model.Money, error) {
+	if from == to {
+		return amount, nil
+	}
+	if s.converter == nil {
+		return 0, model.ErrUnsupportedCurrency
 	}
 
-	var (
-		maxBid   = float32(0.0)
-		minBid   = float32(math.MaxFloat32)
-		bidCount = 0
-	)
-	for idx := 1; idx < len(auctionHistory); idx++ { // skip a first element as an INIT state
-		if auctionHistory[idx].BidValue > maxBid {
-			maxBid = auctionHistory[idx].BidValue
-		}
-		if auctionHistory[idx].BidValue < minBid {
-			minBid = auctionHistory[idx].BidValue
+	return s.converter.Convert(amount, from, to, timestamp)
+}
+
+// addAuditAction saves the change of the order made by the user to the history data
+func (s *Storage) addAuditAction(order *model.Order, actionType model.OrderActionType, timestamp int64, userID int) {
+	s.appendAction(&model.OrderAction{
+		Order:     order,
+		Type:      actionType,
+		Timestamp: timestamp,
+		UserID:    userID,
+		CloseTime: order.CloseTime,
+	})
+}
+
+// appendAction saves the action to the history data of its order
+func (s *Storage) appendAction(action *model.OrderAction) {
+	sh := s.shardOf(action.Order.Item.Name)
+	sh.auctionHistory[action.Order.Item.Name] = append(sh.auctionHistory[action.Order.Item.Name], action)
+	s.markChanged(action.Order.Item.Name)
+
+	statistics := sh.statistics[action.Order.Item.Name]
+	statistics.add(action)
+	sh.statistics[action.Order.Item.Name] = statistics
+}
+
+// isReserveMet checks the leading bid of the order reaches the reserve price
+func isReserveMet(order *model.Order) bool {
+	return order.LastBid >= order.Item.ReservePrice
+}
+
+// isPaid checks the buyer's money is held or paid out to the seller
+func isPaid(order *model.Order) bool {
+	return order.SettlementStatus == model.SettlementStatusPaid || order.SettlementStatus == model.SettlementStatusSettled
+}
+
+// checkOrderIsOpen checks the order still accepts actions at the given time
+func checkOrderIsOpen(order *model.Order, timestamp int64) error {
+	if order.Status != model.OrderStatusInit {
+		return model.ErrAuctionIsClosed
+	}
+	if timestamp > order.CloseTime {
+		return model.ErrAuctionIsFinishedByTime
+	}
+
+	return nil
+}
+
+// getActiveBids returns bids from the auction history that were not retracted or dropped
+func getActiveBids(auctionHistory []*model.OrderAction) []*model.OrderAction {
+	bids := make([]*model.OrderAction, 0, len(auctionHistory))
+	for _, action := range auctionHistory {
+		if action.Type == model.OrderActionTypeBid && !action.Retracted && !action.Dropped {
+			bids = append(bids, action)
 		}
-		bidCount++
 	}
 
-	return auctionHistory[len(auctionHistory)-1].UserID, model.AuctionStatistics{
-		TotalBidCount: bidCount,
-		HighestBid:    maxBid,
-		LowestBid:     minBid,
+	return bids
+}
+
+// getReservePriceAt returns the reserve price in effect at the given time
+func getReservePriceAt(auctionHistory []*model.OrderAction, timestamp int64) model.Money {
+	var reservePrice model.Money
+	for _, action := range auctionHistory {
+		if action.Timestamp > timestamp {
+			continue
+		}
+		if action.Type == model.OrderActionTypeInit || action.Type == model.OrderActionTypeReserveChange {
+			reservePrice = action.ReservePrice
+		}
 	}
+
+	return reservePrice
 }
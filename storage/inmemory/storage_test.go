@@ -2,12 +2,12 @@ package inmemory
 
 import (
 	"context"
-	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
 )
 
 func TestNew(t *testing.T) {
@@ -18,272 +18,208 @@ func TestStorage_CreateOrder(t *testing.T) {
 	order := model.Order{
 		Item: model.Item{
 			Name:         "phone_1",
-			ReservePrice: 20.23,
+			ReservePrice: 2023,
 		},
 		CreationTime: 10,
 		Status:       model.OrderStatusInit,
 		CloseTime:    20,
 	}
+	// the listing reserve price is kept as the original one
+	storedOrder := order
+	storedOrder.OriginalReservePrice = order.Item.ReservePrice
 	auctionInitState := model.OrderAction{
-		Order:    &order,
-		UserID:   0,
-		BidValue: 0,
+		Order:     &storedOrder,
+		Type:      model.OrderActionTypeInit,
+		Timestamp: order.CreationTime,
+		UserID:    0,
+		BidValue:  0,
+
+		ReservePrice: order.Item.ReservePrice,
+		CloseTime:    order.CloseTime,
 	}
 
 	storage := New()
 	err := storage.CreateOrder(context.TODO(), order)
 	assert.NoError(t, err)
 
-	assert.Len(t, storage.orders, 1)
-	assert.Len(t, storage.auctionHistory, 1)
-	assert.EqualValues(t, auctionInitState, *storage.auctionHistory[order.Item.Name][0])
+	assert.Len(t, storage.Snapshot(), 1)
+	assert.Len(t, storage.getHistory(order.Item.Name), 1)
+	assert.EqualValues(t, auctionInitState, *storage.getHistory(order.Item.Name)[0])
 }
 
 func TestStorage_BidOrder(t *testing.T) {
+	order := storagetest.GenerateOrders(1)[0]
+	bid := model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 1545}
+
+	s := New()
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), bid))
+
+	// the bid is saved to the history of the order
+	newOrder := order
+	newOrder.LastBid = bid.BidAmount
+	newOrder.OriginalReservePrice = order.Item.ReservePrice
+	orderAction := model.OrderAction{
+		Order:     &newOrder,
+		Type:      model.OrderActionTypeBid,
+		Timestamp: bid.Timestamp,
+		UserID:    bid.UserID,
+		BidValue:  bid.BidAmount,
+
+		NativeBidValue: bid.BidAmount,
+	}
+	assert.EqualValues(t, newOrder, *s.getOrder(order.Item.Name))
+	assert.Len(t, s.getHistory(order.Item.Name), 2)
+	assert.EqualValues(t, orderAction, *s.getHistory(order.Item.Name)[1])
+}
+
+// testConverter converts money by fixed rates to USD
+type testConverter map[model.Currency]int64
+
+func (c testConverter) Convert(amount model.Money, from, to model.Currency, _ int64) (model.Money, error) {
+	fromRate, ok := c[from]
+	if !ok {
+		return 0, model.ErrUnsupportedCurrency
+	}
+	toRate, ok := c[to]
+	if !ok {
+		return 0, model.ErrUnsupportedCurrency
+	}
+
+	return amount.MulDiv(fromRate, toRate), nil
+}
+
+func TestStorage_Currency(t *testing.T) {
 	itemName := "phone_1"
 	order := model.Order{
 		Item: model.Item{
 			Name:         itemName,
-			ReservePrice: 20,
+			ReservePrice: 2000,
+			Currency:     "EUR",
 		},
+		SellerID:     1,
 		CreationTime: 10,
 		Status:       model.OrderStatusInit,
 		CloseTime:    20,
 	}
 
-	testCases := []struct {
-		name     string
-		init     func() *Storage
-		bidValue model.BidCommand
-		hasErr   bool
-	}{
-		{
-			name: "success",
-			init: func() *Storage {
-				s := New()
-
-				s.CreateOrder(context.TODO(), order)
-
-				return s
-			},
-			bidValue: model.BidCommand{
-				Timestamp: 12,
-				UserID:    3,
-				ItemName:  itemName,
-				BidAmount: 15.45,
-			},
-			hasErr: false,
-		},
-		{
-			name: "err/to_late_bid",
-			init: func() *Storage {
-				s := New()
-
-				s.CreateOrder(context.TODO(), order)
-
-				return s
-			},
-			bidValue: model.BidCommand{
-				Timestamp: 23,
-				UserID:    3,
-				ItemName:  itemName,
-				BidAmount: 15.45,
-			},
-			hasErr: true,
-		},
-		{
-			name: "err/not_found",
-			init: func() *Storage {
-				s := New()
-
-				return s
-			},
-			bidValue: model.BidCommand{
-				Timestamp: 23,
-				UserID:    3,
-				ItemName:  itemName,
-				BidAmount: 15.45,
-			},
-			hasErr: true,
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			s := tc.init()
-			err := s.BidOrder(context.TODO(), tc.bidValue)
-
-			if tc.hasErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				newOrder := order
-				newOrder.LastBid = tc.bidValue.BidAmount
-				orderAction := model.OrderAction{
-					Order:    &newOrder,
-					UserID:   tc.bidValue.UserID,
-					BidValue: tc.bidValue.BidAmount,
-				}
-				assert.EqualValues(t, newOrder, *s.orders[order.Item.Name])
-				assert.Len(t, s.auctionHistory[order.Item.Name], 2)
-				assert.EqualValues(t, orderAction, *s.auctionHistory[order.Item.Name][1])
-			}
-		})
-	}
-}
+	// storage without converter supports the reporting currency only
+	err := New().CreateOrder(context.TODO(), order)
+	assert.ErrorIs(t, err, model.ErrUnsupportedCurrency)
 
-func TestStorage_FinishExpiredAuctions(t *testing.T) {
-	orders := generateOrders(3)
-	orders[2].CloseTime = orders[0].CloseTime // to make order 3 sold by time
+	s := New(WithCurrencyConverter(testConverter{"": 100, "USD": 100, "EUR": 110, "GBP": 120}))
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
 
-	s := New()
-	// create 3 orders
-	err := s.CreateOrder(context.TODO(), orders[0])
-	assert.NoError(t, err)
-	err = s.CreateOrder(context.TODO(), orders[1])
+	// 18.00 GBP = 19.64 EUR, lower than 20.00 USD = 18.18 EUR in comparison
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: 1800, Currency: "GBP"})
 	assert.NoError(t, err)
-	err = s.CreateOrder(context.TODO(), orders[2])
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: itemName, BidAmount: 2000})
 	assert.NoError(t, err)
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 5, ItemName: itemName, BidAmount: 2000, Currency: "JPY"})
+	assert.ErrorIs(t, err, model.ErrUnsupportedCurrency)
 
-	// check we have 3 orders inside
-	assert.Len(t, s.orders, 3)
+	assert.Equal(t, model.Money(1964), s.getOrder(itemName).LastBid)
+	bid := s.getHistory(itemName)[1]
+	assert.Equal(t, model.Money(1964), bid.BidValue)
+	assert.Equal(t, model.Money(1800), bid.NativeBidValue)
+	assert.Equal(t, model.Currency("GBP"), bid.Currency)
 
-	// imitate one bid for order 3
-	err = s.BidOrder(context.TODO(), model.BidCommand{
-		Timestamp: 15,
-		UserID:    3,
-		ItemName:  "phone_3",
-		BidAmount: 22,
-	})
-	assert.NoError(t, err)
-
-	err = s.FinishExpiredAuctions(context.TODO(), 19)
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	results, err := s.GetAuctionResults(context.TODO())
 	assert.NoError(t, err)
-	assert.Equal(t, model.OrderStatusUnsold, s.orders[orders[0].Item.Name].Status)
-	assert.Equal(t, model.OrderStatusInit, s.orders[orders[1].Item.Name].Status)
-	assert.Equal(t, model.OrderStatusSold, s.orders[orders[2].Item.Name].Status)
+	assert.Equal(t, model.ActionResult{
+		CreationTime: 10,
+		CloseTime:    20,
+		Item:         itemName,
+		SellerID:     1,
+		Currency:     "EUR",
+		UserID:       0,
+		Status:       model.OrderStatusUnsold,
+		PricePaid:    0,
+		Statistics: model.AuctionStatistics{
+			TotalBidCount: 2,
+			HighestBid:    1964,
+			LowestBid:     1818,
+		},
+		OriginalReservePrice: 2000,
+		FinalReservePrice:    2000,
+	}, results[0])
 }
 
-func TestStorage_FinishAllAuctions(t *testing.T) {
-	orders := generateOrders(3)
-	orders[2].CloseTime = orders[0].CloseTime // to make order 3 sold by time
+// testFeeCalculator takes 10% buyer's premium and 5% seller commission
+type testFeeCalculator struct{}
 
-	s := New()
-	// create 3 orders
-	err := s.CreateOrder(context.TODO(), orders[0])
-	assert.NoError(t, err)
-	err = s.CreateOrder(context.TODO(), orders[1])
-	assert.NoError(t, err)
-	err = s.CreateOrder(context.TODO(), orders[2])
-	assert.NoError(t, err)
-
-	// check we have 3 orders inside
-	assert.Len(t, s.orders, 3)
-
-	// imitate one bid for order 3
-	err = s.BidOrder(context.TODO(), model.BidCommand{
-		Timestamp: 15,
-		UserID:    3,
-		ItemName:  "phone_3",
-		BidAmount: 22,
-	})
-	assert.NoError(t, err)
-
-	err = s.FinishAllAuctions(context.TODO())
-	assert.NoError(t, err)
-	assert.Equal(t, model.OrderStatusUnsold, s.orders[orders[0].Item.Name].Status)
-	assert.Equal(t, model.OrderStatusUnsold, s.orders[orders[1].Item.Name].Status)
-	assert.Equal(t, model.OrderStatusSold, s.orders[orders[2].Item.Name].Status)
+func (testFeeCalculator) BuyerPremium(hammerPrice model.Money) model.Money {
+	return hammerPrice.MulDiv(10, 100)
 }
 
-func TestStorage_GetAuctionResults(t *testing.T) {
-	expRes := []model.ActionResult{
-		{
-			CreationTime: 10,
-			CloseTime:    15,
-			Item:         "phone_1",
-			UserID:       0,
-			Status:       model.OrderStatusUnsold,
-			PricePaid:    0,
-			Statistics: model.AuctionStatistics{
-				TotalBidCount: 0,
-				HighestBid:    0,
-				LowestBid:     0,
-			},
-		},
-		{
-			CreationTime: 11,
-			CloseTime:    20,
-			Item:         "phone_2",
-			UserID:       0,
-			Status:       model.OrderStatusUnsold,
-			PricePaid:    0,
-			Statistics: model.AuctionStatistics{
-				TotalBidCount: 0,
-				HighestBid:    0,
-				LowestBid:     0,
-			},
-		},
-		{
-			CreationTime: 12,
-			CloseTime:    15,
-			Item:         "phone_3",
-			UserID:       3,
-			Status:       model.OrderStatusSold,
-			PricePaid:    20,
-			Statistics: model.AuctionStatistics{
-				TotalBidCount: 1,
-				HighestBid:    22,
-				LowestBid:     22,
-			},
-		},
-	}
-
-	orders := generateOrders(3)
-	orders[2].CloseTime = orders[0].CloseTime // to make order 3 sold by time
+func (testFeeCalculator) SellerCommission(hammerPrice model.Money) model.Money {
+	return hammerPrice.MulDiv(5, 100)
+}
 
-	s := New()
-	// create 3 orders
-	err := s.CreateOrder(context.TODO(), orders[0])
-	assert.NoError(t, err)
-	err = s.CreateOrder(context.TODO(), orders[1])
-	assert.NoError(t, err)
-	err = s.CreateOrder(context.TODO(), orders[2])
-	assert.NoError(t, err)
+func TestStorage_Fees(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
 
-	// check we have 3 orders inside
-	assert.Len(t, s.orders, 3)
+	s := New(WithFeeCalculator(testFeeCalculator{}))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[1]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_2", BidAmount: 1000}))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
 
-	// imitate one bid for order 3
-	err = s.BidOrder(context.TODO(), model.BidCommand{
-		Timestamp: 15,
-		UserID:    3,
-		ItemName:  "phone_3",
-		BidAmount: 22,
-	})
+	results, err := s.GetAuctionResults(context.TODO())
 	assert.NoError(t, err)
 
-	err = s.FinishAllAuctions(context.TODO())
-	assert.NoError(t, err)
+	// fees are computed from the close bid of the sold order
+	assert.Equal(t, model.OrderStatusSold, results[0].Status)
+	assert.Equal(t, model.Money(2500), results[0].PricePaid)
+	assert.Equal(t, model.Money(250), results[0].BuyerPremium)
+	assert.Equal(t, model.Money(125), results[0].SellerCommission)
 
-	results, err := s.GetAuctionResults(context.TODO())
-	assert.NoError(t, err)
-	assert.EqualValues(t, expRes, results)
+	// unsold order has no fees
+	assert.Equal(t, model.OrderStatusUnsold, results[1].Status)
+	assert.Equal(t, model.Money(0), results[1].BuyerPremium)
+	assert.Equal(t, model.Money(0), results[1].SellerCommission)
 }
 
-func generateOrders(num int) []model.Order {
-	res := make([]model.Order, num)
-	for idx := range res {
-		res[idx] = model.Order{
-			Item: model.Item{
-				Name:         fmt.Sprintf("phone_%d", idx+1),
-				ReservePrice: 20,
-			},
-			CreationTime: int64(10 + idx),
-			Status:       model.OrderStatusInit,
-			CloseTime:    int64(10 + ((idx + 1) * 5)),
-		}
-	}
+func TestStorage_AuditTrail(t *testing.T) {
+	order := storagetest.GenerateOrders(1)[0]
+	itemName := order.Item.Name
+	order.SellerID = 1
+	admin := 99
 
-	return res
+	s := New()
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: 2500}))
+	assert.NoError(t, s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 12, UserID: 1, ItemName: itemName, ReservePrice: 1500}))
+	assert.NoError(t, s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 13, UserID: admin, ItemName: itemName, CloseTime: 40}))
+	assert.NoError(t, s.ForceCloseAuction(context.TODO(), model.AdminCommand{Timestamp: 14, UserID: admin, ItemName: itemName}))
+	assert.NoError(t, s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 15, UserID: admin, ItemName: itemName, CloseTime: 50}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 16, UserID: 4, ItemName: itemName, BidAmount: 3000}))
+	assert.NoError(t, s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 17, UserID: admin, ItemName: itemName}))
+
+	// closed orders are not closed again by heartbeats
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 60))
+
+	// changes of sellers and admins are timestamped in the auction history
+	var auditTrail []model.OrderActionType
+	for _, action := range s.getHistory(itemName) {
+		auditTrail = append(auditTrail, action.Type)
+	}
+	assert.Equal(t, []model.OrderActionType{
+		model.OrderActionTypeInit,
+		model.OrderActionTypeBid,
+		model.OrderActionTypeReserveChange,
+		model.OrderActionTypeExtend,
+		model.OrderActionTypeForceClose,
+		model.OrderActionTypeReopen,
+		model.OrderActionTypeBid,
+		model.OrderActionTypeVoid,
+	}, auditTrail)
+
+	reserveChange := s.getHistory(itemName)[2]
+	assert.Equal(t, int64(12), reserveChange.Timestamp)
+	assert.Equal(t, 1, reserveChange.UserID)
+	assert.Equal(t, model.Money(1500), reserveChange.ReservePrice)
 }
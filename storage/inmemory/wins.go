@@ -0,0 +1,84 @@
+package inmemory
+
+import (
+	"github.com/senseyman/auction-house/model"
+)
+
+// WithMaxWinsPerBidder sets the max number of items a bidder may win across the sale, 0 means no limit.
+// Once the bidder wins the max number of items, their bids on open auctions are dropped
+func WithMaxWinsPerBidder(maxWins int) Option {
+	return func(s *Storage) {
+		s.maxWins = maxWins
+	}
+}
+
+// countWin moves the sold item from the previous buyer to the new one, 0 means no buyer
+func (s *Storage) countWin(prevUserID, userID int) {
+	s.usersMx.Lock()
+	defer s.usersMx.Unlock()
+
+	if prevUserID != 0 {
+		s.wins[prevUserID]--
+		if s.wins[prevUserID] <= 0 {
+			delete(s.wins, prevUserID)
+		}
+	}
+	if userID != 0 {
+		s.wins[userID]++
+	}
+}
+
+// hasMaxWins checks the user has won the max number of items
+func (s *Storage) hasMaxWins(userID int) bool {
+	if s.maxWins == 0 {
+		return false
+	}
+
+	s.usersMx.Lock()
+	defer s.usersMx.Unlock()
+
+	return s.wins[userID] >= s.maxWins
+}
+
+// dropBidsOnMaxWins drops bids of the user who has won the max number of items on open auctions.
+// The leading bid, the price and the exposure of every affected auction are recomputed without them
+func (s *Storage) dropBidsOnMaxWins(userID int, timestamp int64) error {
+	if !s.hasMaxWins(userID) {
+		return nil
+	}
+
+	// all shards are locked by the operation closing the order
+	for _, sh := range s.shards {
+		for _, order := range sh.orders {
+			if err := s.dropBids(order, userID, timestamp); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// dropBids drops bids of the user on the open order
+func (s *Storage) dropBids(order *model.Order, userID int, timestamp int64) error {
+	if order.Status != model.OrderStatusInit {
+		return nil
+	}
+
+	var dropped bool
+	for _, bid := range getActiveBids(s.getHistory(order.Item.Name)) {
+		if bid.UserID == userID {
+			bid.Dropped = true
+			dropped = true
+		}
+	}
+	if !dropped {
+		return nil
+	}
+
+	s.addAuditAction(order, model.OrderActionTypeBidsDrop, timestamp, userID)
+	s.recountStatistics(order.Item.Name)
+	order.LastBid = s.getStatistics(order.Item.Name).leading()
+
+	return s.refreshExposure(order)
+}
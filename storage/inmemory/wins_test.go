@@ -0,0 +1,84 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func TestStorage_MaxWinsPerBidder(t *testing.T) {
+	orders := storagetest.GenerateOrders(3)
+
+	s := New(WithMaxWinsPerBidder(1))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	bids := []model.BidCommand{
+		{Timestamp: 13, UserID: 3, ItemName: "phone_1", BidAmount: 3000},
+		{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 2500},
+		{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 4000},
+		{Timestamp: 13, UserID: 4, ItemName: "phone_2", BidAmount: 3500},
+		{Timestamp: 13, UserID: 5, ItemName: "phone_2", BidAmount: 2200},
+		{Timestamp: 13, UserID: 3, ItemName: "phone_3", BidAmount: 5000},
+		{Timestamp: 13, UserID: 4, ItemName: "phone_3", BidAmount: 4500},
+		{Timestamp: 13, UserID: 5, ItemName: "phone_3", BidAmount: 2100},
+	}
+	for _, bid := range bids {
+		assert.NoError(t, s.BidOrder(context.TODO(), bid))
+	}
+
+	// user 3 wins phone_1 and their bids on other lots are dropped
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	assert.Equal(t, model.Money(3500), s.getOrder("phone_2").LastBid)
+	assert.Equal(t, exposure{userID: 4, amount: 3500}, s.leaderExposure["phone_2"])
+	lastAction := s.getHistory("phone_2")[len(s.getHistory("phone_2"))-1]
+	assert.Equal(t, model.OrderActionTypeBidsDrop, lastAction.Type)
+	assert.Equal(t, 3, lastAction.UserID)
+
+	// user 3 can't bid anymore
+	err := s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 17, UserID: 3, ItemName: "phone_2", BidAmount: 6000})
+	assert.ErrorIs(t, err, model.ErrMaxWinsReached)
+	// the dropped bid of user 3 can't be retracted
+	err = s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 17, UserID: 3, ItemName: "phone_2"})
+	assert.ErrorIs(t, err, model.ErrBidNotFound)
+	assert.Equal(t, model.Money(3500), s.getOrder("phone_2").LastBid)
+
+	// auctions are closed in the close time order, user 4 wins phone_2 and loses phone_3
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results[0].UserID)
+	assert.Equal(t, model.Money(2500), results[0].PricePaid)
+	assert.Equal(t, 4, results[1].UserID)
+	assert.Equal(t, model.Money(2200), results[1].PricePaid)
+	// the dropped bid of user 3 is counted in statistics, but doesn't set the price
+	assert.Equal(t, model.AuctionStatistics{TotalBidCount: 3, HighestBid: 4000, LowestBid: 2200}, results[1].Statistics)
+	assert.Equal(t, 5, results[2].UserID)
+	assert.Equal(t, model.Money(2000), results[2].PricePaid)
+
+	// the second chance is not offered to the bidder who has won the max number of items
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 30, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusDefaulted}))
+	assert.Equal(t, model.OrderStatusUnsold, s.getOrder("phone_1").Status)
+	assert.Equal(t, map[int]int{4: 1, 5: 1}, s.wins)
+}
+
+func TestStorage_NoMaxWinsPerBidder(t *testing.T) {
+	orders := storagetest.GenerateOrders(2)
+
+	s := New()
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: order.Item.Name, BidAmount: 3000}))
+	}
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results[0].UserID)
+	assert.Equal(t, 3, results[1].UserID)
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order once, the index of the migration plus one is the schema version
+var migrations = []string{
+	`
+CREATE TABLE orders (
+	name                   TEXT PRIMARY KEY,
+	seller_id              INTEGER NOT NULL,
+	creation_time          INTEGER NOT NULL,
+	start_time             INTEGER NOT NULL,
+	close_time             INTEGER NOT NULL,
+	status                 TEXT NOT NULL,
+	currency               TEXT NOT NULL,
+	start_price            INTEGER NOT NULL,
+	reserve_price          INTEGER NOT NULL,
+	original_reserve_price INTEGER NOT NULL,
+	deposit_rate           INTEGER NOT NULL,
+	last_bid               INTEGER NOT NULL,
+	close_bid              INTEGER NOT NULL,
+	reserve_met            BOOLEAN NOT NULL,
+	winner_id              INTEGER NOT NULL,
+	buyer_premium          INTEGER NOT NULL,
+	seller_commission      INTEGER NOT NULL,
+	settlement_status      TEXT NOT NULL,
+	offers                 TEXT NOT NULL, -- JSON array of offers
+	deposits               TEXT NOT NULL  -- JSON array of deposits
+);
+
+CREATE INDEX orders_status_close_time ON orders (status, close_time);
+
+-- bids and other changes of auctions (audit trail) in the order they happened
+CREATE TABLE auction_history (
+	item             TEXT NOT NULL,
+	seq              INTEGER NOT NULL,
+	type             INTEGER NOT NULL,
+	timestamp        INTEGER NOT NULL,
+	user_id          INTEGER NOT NULL,
+	bid_value        INTEGER NOT NULL,
+	retracted        BOOLEAN NOT NULL,
+	dropped          BOOLEAN NOT NULL,
+	native_bid_value INTEGER NOT NULL,
+	currency         TEXT NOT NULL,
+	reserve_price    INTEGER NOT NULL,
+	close_time       INTEGER NOT NULL, -- close time in effect since the action
+	outcome          TEXT NOT NULL,    -- outcome of the offer of the payment action
+	PRIMARY KEY (item, seq)
+);
+
+-- type 1 is the bid action
+CREATE VIEW bids AS
+SELECT item, seq, timestamp, user_id, bid_value, native_bid_value, currency, retracted, dropped
+FROM auction_history
+WHERE type = 1;
+
+CREATE TABLE results (
+	item                   TEXT PRIMARY KEY,
+	creation_time          INTEGER NOT NULL,
+	start_time             INTEGER NOT NULL,
+	close_time             INTEGER NOT NULL,
+	currency               TEXT NOT NULL,
+	seller_id              INTEGER NOT NULL,
+	user_id                INTEGER NOT NULL,
+	status                 TEXT NOT NULL,
+	price_paid             INTEGER NOT NULL,
+	start_price            INTEGER NOT NULL,
+	reserve_met            BOOLEAN NOT NULL,
+	original_reserve_price INTEGER NOT NULL,
+	final_reserve_price    INTEGER NOT NULL,
+	buyer_premium          INTEGER NOT NULL,
+	seller_commission      INTEGER NOT NULL,
+	settlement_status      TEXT NOT NULL,
+	offers                 TEXT NOT NULL,
+	deposits               TEXT NOT NULL,
+	total_bid_count        INTEGER NOT NULL,
+	highest_bid            INTEGER NOT NULL,
+	lowest_bid             INTEGER NOT NULL
+);`,
+	`
+-- money movements of sold orders posted to the ledger in the order they happened
+CREATE TABLE ledger_events (
+	seq               INTEGER PRIMARY KEY,
+	type              TEXT NOT NULL,
+	timestamp         INTEGER NOT NULL,
+	item              TEXT NOT NULL,
+	currency          TEXT NOT NULL,
+	seller_id         INTEGER NOT NULL,
+	buyer_id          INTEGER NOT NULL,
+	price             INTEGER NOT NULL,
+	buyer_premium     INTEGER NOT NULL,
+	seller_commission INTEGER NOT NULL
+);`,
+}
+
+// migrate brings the database schema to the latest version
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported %d", version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		if err := applyMigration(ctx, db, version+1, migrations[version]); err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version int, migration string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
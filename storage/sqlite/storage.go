@@ -0,0 +1,106 @@
+// Package sqlite provides the auction storage persisted to the SQLite database file.
+// Every call is saved in one transaction, the state is loaded back when the database is opened.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/writethrough"
+)
+
+// Storage stores auction data in the SQLite database.
+type Storage struct {
+	*writethrough.Storage
+
+	db *sql.DB
+}
+
+// New opens the database file, creating it if needed, migrates the schema and loads the saved auctions.
+// Options configure auction policies the same way as for the in-memory storage
+func New(ctx context.Context, path string, opts ...inmemory.Option) (*Storage, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// one connection serializes writes and keeps in-memory databases alive
+	db.SetMaxOpenConns(1)
+
+	if err = migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	storage, err := writethrough.New(ctx, store{db: db}, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Storage{
+		Storage: storage,
+		db:      db,
+	}, nil
+}
+
+// Close closes the database
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// GetAuctionResults provides results of all auctions from the results table
+func (s *Storage) GetAuctionResults(ctx context.Context) ([]model.ActionResult, error) {
+	return getResults(ctx, s.db)
+}
+
+// store saves orders to database tables
+type store struct {
+	db *sql.DB
+}
+
+// Load reads all orders with their auction history and ledger events
+func (st store) Load(ctx context.Context) ([]inmemory.OrderState, []model.LedgerEvent, error) {
+	states, err := getOrderStates(ctx, st.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	events, err := getLedgerEvents(ctx, st.db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return states, events, nil
+}
+
+// Save writes the changed orders, their new history actions, results and new ledger events in one transaction
+func (st store) Save(ctx context.Context, changes []inmemory.OrderState, events []model.LedgerEvent) error {
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, state := range changes {
+		if err = saveOrder(ctx, tx, state.Order); err != nil {
+			return err
+		}
+		if err = saveHistory(ctx, tx, state.Order.Item.Name, state.History); err != nil {
+			return err
+		}
+		if err = saveResult(ctx, tx, state.Result); err != nil {
+			return err
+		}
+	}
+	for _, event := range events {
+		if err = saveLedgerEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
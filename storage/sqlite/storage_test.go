@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/auction"
+	"github.com/senseyman/auction-house/service/ledger"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+func newTestStorage(t *testing.T, path string, opts ...inmemory.Option) *Storage {
+	s, err := New(context.TODO(), path, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		s.Close()
+	})
+
+	return s
+}
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) auction.Storage {
+		return newTestStorage(t, filepath.Join(t.TempDir(), "auction.db"))
+	})
+}
+
+func TestStorage_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auction.db")
+	orders := storagetest.GenerateOrders(3)
+
+	ledgerService := ledger.New()
+	s := newTestStorage(t, path, inmemory.WithRetractionWindow(5), inmemory.WithLedger(ledgerService))
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 2200}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 5, ItemName: "phone_2", BidAmount: 2100}))
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 15, UserID: 3, ItemName: "phone_2"}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	expRes, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	expBalance := ledgerService.TrialBalance()
+	assert.NotEmpty(t, expBalance)
+	assert.NoError(t, s.Close())
+
+	// the state and the ledger are loaded back when the database is opened again
+	ledgerService = ledger.New()
+	s = newTestStorage(t, path, inmemory.WithRetractionWindow(5), inmemory.WithLedger(ledgerService))
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, expRes, results)
+	assert.Equal(t, expBalance, ledgerService.TrialBalance())
+
+	// rules apply to the loaded state: the retracted bid stays retracted, closed auctions are not closed again
+	err = s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 17, UserID: 3, ItemName: "phone_2"})
+	assert.ErrorIs(t, err, model.ErrBidNotFound)
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 17, UserID: 3, ItemName: "phone_1", BidAmount: 5000})
+	assert.ErrorIs(t, err, model.ErrAuctionIsClosed)
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 18, UserID: 4, ItemName: "phone_1", Outcome: model.OfferStatusPaid}))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	results, err = s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, model.SettlementStatusPaid, results[0].SettlementStatus)
+	assert.Equal(t, model.OrderStatusSold, results[1].Status)
+	assert.Equal(t, 5, results[1].UserID)
+	assert.Equal(t, model.Money(2000), results[1].PricePaid)
+	assert.Equal(t, model.OrderStatusUnsold, results[2].Status)
+}
+
+func TestStorage_Tables(t *testing.T) {
+	s := newTestStorage(t, filepath.Join(t.TempDir(), "auction.db"))
+	orders := storagetest.GenerateOrders(1)
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 2100}))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	var status string
+	assert.NoError(t, s.db.QueryRow(`SELECT status FROM orders WHERE name = 'phone_1'`).Scan(&status))
+	assert.Equal(t, "SOLD", status)
+
+	var bidCount, historyCount int
+	assert.NoError(t, s.db.QueryRow(`SELECT COUNT(*) FROM bids WHERE item = 'phone_1'`).Scan(&bidCount))
+	assert.NoError(t, s.db.QueryRow(`SELECT COUNT(*) FROM auction_history WHERE item = 'phone_1'`).Scan(&historyCount))
+	assert.Equal(t, 2, bidCount)
+	assert.Equal(t, 3, historyCount)
+
+	var userID, pricePaid int
+	assert.NoError(t, s.db.QueryRow(`SELECT user_id, price_paid FROM results WHERE item = 'phone_1'`).Scan(&userID, &pricePaid))
+	assert.Equal(t, 3, userID)
+	assert.Equal(t, 2100, pricePaid)
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auction.db")
+
+	// migrations are applied once
+	newTestStorage(t, path).Close()
+	s := newTestStorage(t, path)
+
+	var version int
+	assert.NoError(t, s.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	assert.Equal(t, len(migrations), version)
+}
@@ -0,0 +1,256 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/inmemory"
+)
+
+// saveOrder inserts or updates the order row
+func saveOrder(ctx context.Context, tx *sql.Tx, order model.Order) error {
+	offers, err := json.Marshal(order.Offers)
+	if err != nil {
+		return err
+	}
+	deposits, err := json.Marshal(order.Deposits)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT OR REPLACE INTO orders (
+	name, seller_id, creation_time, start_time, close_time, status, currency, start_price, reserve_price,
+	deposit_rate, last_bid, close_bid, reserve_met, winner_id, buyer_premium, seller_commission,
+	settlement_status, offers, deposits, original_reserve_price
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		order.Item.Name, order.SellerID, order.CreationTime, order.StartTime, order.CloseTime, order.Status,
+		order.Item.Currency, order.Item.StartPrice, order.Item.ReservePrice, order.Item.DepositRate,
+		order.LastBid, order.CloseBid, order.ReserveMet, order.WinnerID, order.BuyerPremium, order.SellerCommission,
+		order.SettlementStatus, string(offers), string(deposits), order.OriginalReservePrice,
+	)
+
+	return err
+}
+
+// saveHistory inserts new actions of the auction history and updates flags of retracted and dropped bids.
+// Saved actions are never changed otherwise
+func saveHistory(ctx context.Context, tx *sql.Tx, itemName string, history []model.OrderAction) error {
+	var saved int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM auction_history WHERE item = ?`, itemName).Scan(&saved); err != nil {
+		return err
+	}
+	for seq, action := range history {
+		if seq < saved {
+			if !action.Retracted && !action.Dropped {
+				continue
+			}
+			_, err := tx.ExecContext(ctx, `UPDATE auction_history SET retracted = ?, dropped = ? WHERE item = ? AND seq = ?`,
+				action.Retracted, action.Dropped, itemName, seq)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `
+INSERT INTO auction_history (
+	item, seq, type, timestamp, user_id, bid_value, retracted, dropped, native_bid_value, currency, reserve_price, close_time, outcome
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			itemName, seq, action.Type, action.Timestamp, action.UserID, action.BidValue, action.Retracted, action.Dropped,
+			action.NativeBidValue, action.Currency, action.ReservePrice, action.CloseTime, action.Outcome,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveResult inserts or updates the auction result row
+func saveResult(ctx context.Context, tx *sql.Tx, result model.ActionResult) error {
+	offers, err := json.Marshal(result.Offers)
+	if err != nil {
+		return err
+	}
+	deposits, err := json.Marshal(result.Deposits)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT OR REPLACE INTO results (
+	item, creation_time, start_time, close_time, currency, seller_id, user_id, status, price_paid, start_price,
+	reserve_met, original_reserve_price, final_reserve_price, buyer_premium, seller_commission, settlement_status,
+	offers, deposits, total_bid_count, highest_bid, lowest_bid
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		result.Item, result.CreationTime, result.StartTime, result.CloseTime, result.Currency, result.SellerID,
+		result.UserID, result.Status, result.PricePaid, result.StartPrice, result.ReserveMet,
+		result.OriginalReservePrice, result.FinalReservePrice, result.BuyerPremium, result.SellerCommission,
+		result.SettlementStatus, string(offers), string(deposits),
+		result.Statistics.TotalBidCount, result.Statistics.HighestBid, result.Statistics.LowestBid,
+	)
+
+	return err
+}
+
+// saveLedgerEvent appends the ledger event
+func saveLedgerEvent(ctx context.Context, tx *sql.Tx, event model.LedgerEvent) error {
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO ledger_events (
+	type, timestamp, item, currency, seller_id, buyer_id, price, buyer_premium, seller_commission
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.Type, event.Timestamp, event.Item, event.Currency, event.SellerID, event.BuyerID,
+		event.Price, event.BuyerPremium, event.SellerCommission,
+	)
+
+	return err
+}
+
+// getOrderStates reads all orders with their auction history
+func getOrderStates(ctx context.Context, db *sql.DB) ([]inmemory.OrderState, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT name, seller_id, creation_time, start_time, close_time, status, currency, start_price, reserve_price,
+	deposit_rate, last_bid, close_bid, reserve_met, winner_id, buyer_premium, seller_commission,
+	settlement_status, offers, deposits, original_reserve_price
+FROM orders
+ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		states  []inmemory.OrderState
+		indexes = make(map[string]int) // key - order name, value - index in states
+	)
+	for rows.Next() {
+		var (
+			order            model.Order
+			offers, deposits string
+		)
+		err = rows.Scan(
+			&order.Item.Name, &order.SellerID, &order.CreationTime, &order.StartTime, &order.CloseTime, &order.Status,
+			&order.Item.Currency, &order.Item.StartPrice, &order.Item.ReservePrice, &order.Item.DepositRate,
+			&order.LastBid, &order.CloseBid, &order.ReserveMet, &order.WinnerID, &order.BuyerPremium, &order.SellerCommission,
+			&order.SettlementStatus, &offers, &deposits, &order.OriginalReservePrice,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(offers), &order.Offers); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(deposits), &order.Deposits); err != nil {
+			return nil, err
+		}
+
+		indexes[order.Item.Name] = len(states)
+		states = append(states, inmemory.OrderState{Order: order})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	historyRows, err := db.QueryContext(ctx, `
+SELECT item, type, timestamp, user_id, bid_value, retracted, dropped, native_bid_value, currency, reserve_price, close_time, outcome
+FROM auction_history
+ORDER BY item, seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var (
+			itemName string
+			action   model.OrderAction
+		)
+		err = historyRows.Scan(
+			&itemName, &action.Type, &action.Timestamp, &action.UserID, &action.BidValue, &action.Retracted, &action.Dropped,
+			&action.NativeBidValue, &action.Currency, &action.ReservePrice, &action.CloseTime, &action.Outcome,
+		)
+		if err != nil {
+			return nil, err
+		}
+		idx, ok := indexes[itemName]
+		if !ok {
+			continue
+		}
+		states[idx].History = append(states[idx].History, action)
+	}
+
+	return states, historyRows.Err()
+}
+
+// getResults reads results of all auctions in the creation time order
+func getResults(ctx context.Context, db *sql.DB) ([]model.ActionResult, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT item, creation_time, start_time, close_time, currency, seller_id, user_id, status, price_paid, start_price,
+	reserve_met, original_reserve_price, final_reserve_price, buyer_premium, seller_commission, settlement_status,
+	offers, deposits, total_bid_count, highest_bid, lowest_bid
+FROM results
+ORDER BY creation_time, item`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]model.ActionResult, 0)
+	for rows.Next() {
+		var (
+			result           model.ActionResult
+			offers, deposits string
+		)
+		err = rows.Scan(
+			&result.Item, &result.CreationTime, &result.StartTime, &result.CloseTime, &result.Currency, &result.SellerID,
+			&result.UserID, &result.Status, &result.PricePaid, &result.StartPrice, &result.ReserveMet,
+			&result.OriginalReservePrice, &result.FinalReservePrice, &result.BuyerPremium, &result.SellerCommission,
+			&result.SettlementStatus, &offers, &deposits,
+			&result.Statistics.TotalBidCount, &result.Statistics.HighestBid, &result.Statistics.LowestBid,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(offers), &result.Offers); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(deposits), &result.Deposits); err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// getLedgerEvents reads all ledger events in the order they were saved
+func getLedgerEvents(ctx context.Context, db *sql.DB) ([]model.LedgerEvent, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT type, timestamp, item, currency, seller_id, buyer_id, price, buyer_premium, seller_commission
+FROM ledger_events
+ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.LedgerEvent
+	for rows.Next() {
+		var event model.LedgerEvent
+		err = rows.Scan(
+			&event.Type, &event.Timestamp, &event.Item, &event.Currency, &event.SellerID, &event.BuyerID,
+			&event.Price, &event.BuyerPremium, &event.SellerCommission,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
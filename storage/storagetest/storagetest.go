@@ -0,0 +1,934 @@
+// Package storagetest provides the conformance suite every auction.Storage backend has to pass
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/auction"
+)
+
+// Run runs all scenarios of the conformance suite against the backend.
+// Every scenario creates its own empty storage with default auction policies by calling newStorage
+func Run(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, newStorage func(t *testing.T) auction.Storage)
+	}{
+		{name: "BidOrder", run: testBidOrder},
+		{name: "WinnerAndPrice", run: testWinnerAndPrice},
+		{name: "StartPrice", run: testStartPrice},
+		{name: "ZeroReserve", run: testZeroReserve},
+		{name: "CancelOrder", run: testCancelOrder},
+		{name: "RetractBid", run: testRetractBid},
+		{name: "LowerReserve", run: testLowerReserve},
+		{name: "AdminActions", run: testAdminActions},
+		{name: "AdminActionsNotAllowed", run: testAdminActionsNotAllowed},
+		{name: "FinishExpiredAuctions", run: testFinishExpiredAuctions},
+		{name: "FinishAllAuctions", run: testFinishAllAuctions},
+		{name: "GetAuctionResults", run: testGetAuctionResults},
+		{name: "Payments", run: testPayments},
+		{name: "Deposits", run: testDeposits},
+		{name: "Queries", run: testQueries},
+		{name: "PointInTime", run: testPointInTime},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			scenario.run(t, newStorage)
+		})
+	}
+}
+
+// GenerateOrders returns orders phone_1, phone_2, ... created and closed 1 and 5 seconds apart
+func GenerateOrders(num int) []model.Order {
+	res := make([]model.Order, num)
+	for idx := range res {
+		res[idx] = model.Order{
+			Item: model.Item{
+				Name:         fmt.Sprintf("phone_%d", idx+1),
+				ReservePrice: 2000,
+			},
+			CreationTime: int64(10 + idx),
+			Status:       model.OrderStatusInit,
+			CloseTime:    int64(10 + ((idx + 1) * 5)),
+		}
+	}
+
+	return res
+}
+
+func getResult(t *testing.T, s auction.Storage, itemName string) model.ActionResult {
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	for _, result := range results {
+		if result.Item == itemName {
+			return result
+		}
+	}
+	t.Fatalf("no result of %s", itemName)
+
+	return model.ActionResult{}
+}
+
+func testBidOrder(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name:         itemName,
+			ReservePrice: 2000,
+		},
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    20,
+	}
+
+	testCases := []struct {
+		name  string
+		order *model.Order
+		bid   model.BidCommand
+		err   error
+	}{
+		{
+			name:  "success",
+			order: &order,
+			bid:   model.BidCommand{Timestamp: 12, UserID: 3, ItemName: itemName, BidAmount: 1545},
+		},
+		{
+			name:  "err/to_late_bid",
+			order: &order,
+			bid:   model.BidCommand{Timestamp: 23, UserID: 3, ItemName: itemName, BidAmount: 1545},
+			err:   model.ErrAuctionIsFinishedByTime,
+		},
+		{
+			name: "err/not_started",
+			order: func() *model.Order {
+				scheduledOrder := order
+				scheduledOrder.StartTime = 15
+				return &scheduledOrder
+			}(),
+			bid: model.BidCommand{Timestamp: 12, UserID: 3, ItemName: itemName, BidAmount: 1545},
+			err: model.ErrAuctionIsNotStarted,
+		},
+		{
+			name: "err/bid_is_too_low",
+			order: func() *model.Order {
+				pricedOrder := order
+				pricedOrder.Item.StartPrice = 1600
+				return &pricedOrder
+			}(),
+			bid: model.BidCommand{Timestamp: 12, UserID: 3, ItemName: itemName, BidAmount: 1545},
+			err: model.ErrBidIsTooLow,
+		},
+		{
+			name: "err/not_found",
+			bid:  model.BidCommand{Timestamp: 12, UserID: 3, ItemName: itemName, BidAmount: 1545},
+			err:  model.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newStorage(t)
+			if tc.order != nil {
+				assert.NoError(t, s.CreateOrder(context.TODO(), *tc.order))
+			}
+
+			err := s.BidOrder(context.TODO(), tc.bid)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, model.AuctionStatistics{
+				TotalBidCount: 1,
+				HighestBid:    tc.bid.BidAmount,
+				LowestBid:     tc.bid.BidAmount,
+			}, getResult(t, s, itemName).Statistics)
+		})
+	}
+}
+
+func testWinnerAndPrice(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	order := GenerateOrders(1)[0]
+
+	testCases := []struct {
+		name     string
+		bids     []model.BidCommand
+		expUser  int
+		expPrice model.Money
+	}{
+		{
+			// the last bidder used to win at the second last bid: user 5 at 3000
+			name: "later_lower_bid",
+			bids: []model.BidCommand{
+				{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500},
+				{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 3000},
+				{Timestamp: 13, UserID: 5, ItemName: "phone_1", BidAmount: 2200},
+			},
+			expUser:  4,
+			expPrice: 2500,
+		},
+		{
+			// the second last bid used to be the price: 2100
+			name: "second_highest_bid",
+			bids: []model.BidCommand{
+				{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2800},
+				{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 2100},
+				{Timestamp: 13, UserID: 5, ItemName: "phone_1", BidAmount: 3000},
+			},
+			expUser:  5,
+			expPrice: 2800,
+		},
+		{
+			// the last of equal bids used to win: user 4
+			name: "equal_bids",
+			bids: []model.BidCommand{
+				{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500},
+				{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 2500},
+			},
+			expUser:  3,
+			expPrice: 2500,
+		},
+		{
+			name: "one_bid",
+			bids: []model.BidCommand{
+				{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500},
+			},
+			expUser:  3,
+			expPrice: 2000,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newStorage(t)
+			assert.NoError(t, s.CreateOrder(context.TODO(), order))
+			for _, bid := range tc.bids {
+				assert.NoError(t, s.BidOrder(context.TODO(), bid))
+			}
+			assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+			result := getResult(t, s, "phone_1")
+			assert.Equal(t, model.OrderStatusSold, result.Status)
+			assert.Equal(t, tc.expUser, result.UserID)
+			assert.Equal(t, tc.expPrice, result.PricePaid)
+		})
+	}
+
+	// closed orders used to be closed again by every heartbeat, now they keep their results
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	before, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 30))
+	after, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func testStartPrice(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name:         itemName,
+			StartPrice:   500,
+			ReservePrice: 2000,
+		},
+		SellerID:     1,
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    20,
+	}
+
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	err := s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: 400})
+	assert.ErrorIs(t, err, model.ErrBidIsTooLow)
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: itemName, BidAmount: 500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: itemName, BidAmount: 1500}))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	assert.Equal(t, model.ActionResult{
+		CreationTime:         10,
+		CloseTime:            20,
+		Item:                 itemName,
+		SellerID:             1,
+		Status:               model.OrderStatusUnsold,
+		StartPrice:           500,
+		OriginalReservePrice: 2000,
+		FinalReservePrice:    2000,
+		Statistics: model.AuctionStatistics{
+			TotalBidCount: 2,
+			HighestBid:    1500,
+			LowestBid:     500,
+		},
+	}, getResult(t, s, itemName))
+}
+
+func testZeroReserve(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name: itemName,
+		},
+		SellerID:     1,
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    20,
+	}
+
+	// the item without the reserve price is sold without bids, there is nobody to pay
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Equal(t, model.ActionResult{
+		CreationTime: 10,
+		CloseTime:    20,
+		Item:         itemName,
+		SellerID:     1,
+		Status:       model.OrderStatusSold,
+		ReserveMet:   true,
+	}, getResult(t, s, itemName))
+
+	// the seller may withdraw it until the first bid
+	s = newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.CancelOrder(context.TODO(), model.CancelCommand{Timestamp: 11, UserID: 1, ItemName: itemName}))
+	s = newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: 100}))
+	err := s.CancelOrder(context.TODO(), model.CancelCommand{Timestamp: 12, UserID: 1, ItemName: itemName})
+	assert.ErrorIs(t, err, model.ErrReserveIsMet)
+}
+
+func testCancelOrder(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name:         itemName,
+			ReservePrice: 2000,
+		},
+		SellerID:     1,
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    20,
+	}
+
+	testCases := []struct {
+		name   string
+		bids   []model.Money
+		cancel model.CancelCommand
+		err    error
+	}{
+		{
+			name:   "success",
+			bids:   []model.Money{1500},
+			cancel: model.CancelCommand{Timestamp: 12, UserID: 1, ItemName: itemName},
+		},
+		{
+			name:   "err/not_seller",
+			cancel: model.CancelCommand{Timestamp: 12, UserID: 2, ItemName: itemName},
+			err:    model.ErrNotSeller,
+		},
+		{
+			name:   "err/reserve_is_met",
+			bids:   []model.Money{2000},
+			cancel: model.CancelCommand{Timestamp: 12, UserID: 1, ItemName: itemName},
+			err:    model.ErrReserveIsMet,
+		},
+		{
+			name:   "err/to_late_cancel",
+			cancel: model.CancelCommand{Timestamp: 21, UserID: 1, ItemName: itemName},
+			err:    model.ErrAuctionIsFinishedByTime,
+		},
+		{
+			name:   "err/not_found",
+			cancel: model.CancelCommand{Timestamp: 12, UserID: 1, ItemName: "phone_2"},
+			err:    model.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newStorage(t)
+			assert.NoError(t, s.CreateOrder(context.TODO(), order))
+			for _, bid := range tc.bids {
+				assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: bid}))
+			}
+
+			err := s.CancelOrder(context.TODO(), tc.cancel)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+
+			// cancelled order doesn't accept bids and is not closed by time
+			err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: itemName, BidAmount: 2500})
+			assert.ErrorIs(t, err, model.ErrAuctionIsClosed)
+			assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 30))
+
+			result := getResult(t, s, itemName)
+			assert.Equal(t, 0, result.UserID)
+			assert.Equal(t, model.OrderStatusCancelled, result.Status)
+			assert.Equal(t, tc.cancel.Timestamp, result.CloseTime)
+		})
+	}
+}
+
+func testRetractBid(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name:         itemName,
+			ReservePrice: 2000,
+		},
+		SellerID:     1,
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    30,
+	}
+	initStorage := func(t *testing.T) auction.Storage {
+		s := newStorage(t)
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: 2100}))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: itemName, BidAmount: 2500}))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 5, ItemName: itemName, BidAmount: 25000}))
+		return s
+	}
+
+	testCases := []struct {
+		name       string
+		retract    model.RetractCommand
+		err        error
+		expHighest model.Money
+	}{
+		{
+			name:       "success/leader",
+			retract:    model.RetractCommand{Timestamp: 15, UserID: 5, ItemName: itemName},
+			expHighest: 2500,
+		},
+		{
+			name:       "success/not_leader",
+			retract:    model.RetractCommand{Timestamp: 15, UserID: 4, ItemName: itemName},
+			expHighest: 25000,
+		},
+		{
+			name:    "err/window_expired",
+			retract: model.RetractCommand{Timestamp: 22, UserID: 3, ItemName: itemName},
+			err:     model.ErrRetractionWindowExpired,
+		},
+		{
+			name:    "err/no_bid",
+			retract: model.RetractCommand{Timestamp: 15, UserID: 6, ItemName: itemName},
+			err:     model.ErrBidNotFound,
+		},
+		{
+			name:    "err/not_found",
+			retract: model.RetractCommand{Timestamp: 15, UserID: 5, ItemName: "phone_2"},
+			err:     model.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := initStorage(t)
+			err := s.RetractBid(context.TODO(), tc.retract)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expHighest, getResult(t, s, itemName).Statistics.HighestBid)
+
+			// the same bid can't be retracted twice
+			err = s.RetractBid(context.TODO(), tc.retract)
+			assert.ErrorIs(t, err, model.ErrBidNotFound)
+		})
+	}
+
+	// winner and price are recomputed after the leader retracts
+	s := initStorage(t)
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 15, UserID: 5, ItemName: itemName}))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Equal(t, model.ActionResult{
+		CreationTime:         10,
+		CloseTime:            30,
+		Item:                 itemName,
+		SellerID:             1,
+		UserID:               4,
+		Status:               model.OrderStatusSold,
+		PricePaid:            2100,
+		ReserveMet:           true,
+		OriginalReservePrice: 2000,
+		FinalReservePrice:    2000,
+		Offers:               []model.Offer{{UserID: 4, Price: 2100, Timestamp: 30, Status: model.OfferStatusPending}},
+		SettlementStatus:     model.SettlementStatusAwaitingPayment,
+		Statistics: model.AuctionStatistics{
+			TotalBidCount: 2,
+			HighestBid:    2500,
+			LowestBid:     2100,
+		},
+	}, getResult(t, s, itemName))
+}
+
+func testLowerReserve(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name:         itemName,
+			ReservePrice: 2000,
+		},
+		SellerID:     1,
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    20,
+	}
+
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: 1200}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: itemName, BidAmount: 1600}))
+
+	err := s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 13, UserID: 2, ItemName: itemName, ReservePrice: 1500})
+	assert.ErrorIs(t, err, model.ErrNotSeller)
+	err = s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 13, UserID: 1, ItemName: itemName, ReservePrice: 2500})
+	assert.ErrorIs(t, err, model.ErrReserveCanNotBeRaised)
+	err = s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 21, UserID: 1, ItemName: itemName, ReservePrice: 1500})
+	assert.ErrorIs(t, err, model.ErrAuctionIsFinishedByTime)
+	err = s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 13, UserID: 1, ItemName: "phone_2", ReservePrice: 1500})
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// the change at the creation time doesn't change the original reserve price
+	assert.NoError(t, s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 10, UserID: 1, ItemName: itemName, ReservePrice: 1900}))
+	assert.NoError(t, s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 14, UserID: 1, ItemName: itemName, ReservePrice: 1800}))
+	assert.NoError(t, s.LowerReserve(context.TODO(), model.ReserveCommand{Timestamp: 15, UserID: 1, ItemName: itemName, ReservePrice: 1500}))
+
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Equal(t, model.ActionResult{
+		CreationTime: 10,
+		CloseTime:    20,
+		Item:         itemName,
+		SellerID:     1,
+		UserID:       4,
+		Status:       model.OrderStatusSold,
+		PricePaid:    1200,
+		ReserveMet:   true,
+		Statistics: model.AuctionStatistics{
+			TotalBidCount: 2,
+			HighestBid:    1600,
+			LowestBid:     1200,
+		},
+		OriginalReservePrice: 2000,
+		FinalReservePrice:    1500,
+		Offers:               []model.Offer{{UserID: 4, Price: 1200, Timestamp: 20, Status: model.OfferStatusPending}},
+		SettlementStatus:     model.SettlementStatusAwaitingPayment,
+	}, getResult(t, s, itemName))
+}
+
+func testAdminActions(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name:         itemName,
+			ReservePrice: 2000,
+		},
+		SellerID:     1,
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    20,
+	}
+
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: itemName, BidAmount: 2500}))
+
+	// extend
+	err := s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 12, UserID: 99, Action: model.AdminActionExtend, ItemName: itemName, CloseTime: 15})
+	assert.ErrorIs(t, err, model.ErrInvalidData)
+	err = s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 12, UserID: 99, Action: model.AdminActionExtend, ItemName: itemName, CloseTime: 40})
+	assert.NoError(t, err)
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 25))
+	assert.Equal(t, model.OrderStatusInit, getResult(t, s, itemName).Status)
+	assert.Equal(t, int64(40), getResult(t, s, itemName).CloseTime)
+
+	// reopen is allowed only for closed auctions
+	err = s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 26, UserID: 99, Action: model.AdminActionReopen, ItemName: itemName, CloseTime: 50})
+	assert.ErrorIs(t, err, model.ErrAuctionIsOpen)
+
+	// force close
+	err = s.ForceCloseAuction(context.TODO(), model.AdminCommand{Timestamp: 27, UserID: 99, Action: model.AdminActionForceClose, ItemName: itemName})
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusSold, getResult(t, s, itemName).Status)
+	assert.Equal(t, int64(27), getResult(t, s, itemName).CloseTime)
+	err = s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 28, UserID: 99, Action: model.AdminActionExtend, ItemName: itemName, CloseTime: 50})
+	assert.ErrorIs(t, err, model.ErrAuctionIsClosed)
+
+	// reopen
+	err = s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 29, UserID: 99, Action: model.AdminActionReopen, ItemName: itemName, CloseTime: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusInit, getResult(t, s, itemName).Status)
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 30, UserID: 4, ItemName: itemName, BidAmount: 3000}))
+
+	// void
+	err = s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 31, UserID: 99, Action: model.AdminActionVoid, ItemName: itemName})
+	assert.NoError(t, err)
+	err = s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 32, UserID: 99, Action: model.AdminActionVoid, ItemName: itemName})
+	assert.ErrorIs(t, err, model.ErrAuctionIsClosed)
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	assert.Equal(t, model.ActionResult{
+		CreationTime:         10,
+		CloseTime:            31,
+		Item:                 itemName,
+		SellerID:             1,
+		Status:               model.OrderStatusVoided,
+		ReserveMet:           true,
+		OriginalReservePrice: 2000,
+		FinalReservePrice:    2000,
+		Statistics: model.AuctionStatistics{
+			TotalBidCount: 2,
+			HighestBid:    3000,
+			LowestBid:     2500,
+		},
+	}, getResult(t, s, itemName))
+
+	// not found
+	err = s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 33, UserID: 99, Action: model.AdminActionVoid, ItemName: "phone_2"})
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func testAdminActionsNotAllowed(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	itemName := "phone_1"
+	order := model.Order{
+		Item: model.Item{
+			Name:         itemName,
+			ReservePrice: 2000,
+		},
+		SellerID:     1,
+		CreationTime: 10,
+		Status:       model.OrderStatusInit,
+		CloseTime:    20,
+	}
+
+	// the auction expired by time can't be extended before the heartbeat closes it, it's reopened instead
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	err := s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 20, UserID: 99, Action: model.AdminActionExtend, ItemName: itemName, CloseTime: 40})
+	assert.ErrorIs(t, err, model.ErrAuctionIsFinishedByTime)
+	assert.Equal(t, int64(20), getResult(t, s, itemName).CloseTime)
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 21))
+	err = s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 22, UserID: 99, Action: model.AdminActionReopen, ItemName: itemName, CloseTime: 40})
+	assert.NoError(t, err)
+
+	// close and void have no close time
+	err = s.ForceCloseAuction(context.TODO(), model.AdminCommand{Timestamp: 23, UserID: 99, Action: model.AdminActionForceClose, ItemName: itemName, CloseTime: 30})
+	assert.ErrorIs(t, err, model.ErrInvalidData)
+	err = s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 23, UserID: 99, Action: model.AdminActionVoid, ItemName: itemName, CloseTime: 30})
+	assert.ErrorIs(t, err, model.ErrInvalidData)
+	assert.Equal(t, model.OrderStatusInit, getResult(t, s, itemName).Status)
+
+	// the item withdrawn by the seller can't be reopened
+	s = newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.CancelOrder(context.TODO(), model.CancelCommand{Timestamp: 12, UserID: 1, ItemName: itemName}))
+	err = s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 13, UserID: 99, Action: model.AdminActionReopen, ItemName: itemName, CloseTime: 40})
+	assert.ErrorIs(t, err, model.ErrAuctionIsCancelled)
+	assert.Equal(t, model.OrderStatusCancelled, getResult(t, s, itemName).Status)
+}
+
+func testFinishExpiredAuctions(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	orders := GenerateOrders(3)
+	orders[2].CloseTime = orders[0].CloseTime // to make order 3 sold by time
+
+	s := newStorage(t)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 15, UserID: 3, ItemName: "phone_3", BidAmount: 2200}))
+
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 19))
+	assert.Equal(t, model.OrderStatusUnsold, getResult(t, s, "phone_1").Status)
+	assert.Equal(t, model.OrderStatusInit, getResult(t, s, "phone_2").Status)
+	assert.Equal(t, model.OrderStatusSold, getResult(t, s, "phone_3").Status)
+}
+
+func testFinishAllAuctions(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	orders := GenerateOrders(3)
+	orders[2].CloseTime = orders[0].CloseTime // to make order 3 sold by time
+
+	s := newStorage(t)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 15, UserID: 3, ItemName: "phone_3", BidAmount: 2200}))
+
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	assert.Equal(t, model.OrderStatusUnsold, getResult(t, s, "phone_1").Status)
+	assert.Equal(t, model.OrderStatusUnsold, getResult(t, s, "phone_2").Status)
+	assert.Equal(t, model.OrderStatusSold, getResult(t, s, "phone_3").Status)
+}
+
+func testGetAuctionResults(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	expRes := []model.ActionResult{
+		{
+			CreationTime:         10,
+			CloseTime:            15,
+			Item:                 "phone_1",
+			Status:               model.OrderStatusUnsold,
+			OriginalReservePrice: 2000,
+			FinalReservePrice:    2000,
+		},
+		{
+			CreationTime:         11,
+			CloseTime:            20,
+			Item:                 "phone_2",
+			Status:               model.OrderStatusUnsold,
+			OriginalReservePrice: 2000,
+			FinalReservePrice:    2000,
+		},
+		{
+			CreationTime:         12,
+			CloseTime:            15,
+			Item:                 "phone_3",
+			UserID:               3,
+			Status:               model.OrderStatusSold,
+			PricePaid:            2000,
+			ReserveMet:           true,
+			OriginalReservePrice: 2000,
+			FinalReservePrice:    2000,
+			Offers:               []model.Offer{{UserID: 3, Price: 2000, Timestamp: 15, Status: model.OfferStatusPending}},
+			SettlementStatus:     model.SettlementStatusAwaitingPayment,
+			Statistics: model.AuctionStatistics{
+				TotalBidCount: 1,
+				HighestBid:    2200,
+				LowestBid:     2200,
+			},
+		},
+	}
+
+	orders := GenerateOrders(3)
+	orders[2].CloseTime = orders[0].CloseTime // to make order 3 sold by time
+
+	s := newStorage(t)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 15, UserID: 3, ItemName: "phone_3", BidAmount: 2200}))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.EqualValues(t, expRes, results)
+}
+
+func testPayments(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	orders := GenerateOrders(3)
+
+	s := newStorage(t)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: order.Item.Name, BidAmount: 3000}))
+		assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 4, ItemName: order.Item.Name, BidAmount: 2500}))
+	}
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	err := s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 20, UserID: 4, ItemName: "phone_1", Outcome: model.OfferStatusPaid})
+	assert.ErrorIs(t, err, model.ErrNotBuyer)
+
+	// phone_1 is paid and settled
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 20, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusPaid}))
+	assert.NoError(t, s.SettleOrder(context.TODO(), model.AdminCommand{Timestamp: 21, UserID: 99, ItemName: "phone_1"}))
+	err = s.VoidAuction(context.TODO(), model.AdminCommand{Timestamp: 22, UserID: 99, ItemName: "phone_1"})
+	assert.ErrorIs(t, err, model.ErrInvalidSettlementStatus)
+	// phone_2 is offered to the second-chance buyer who pays
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 20, UserID: 3, ItemName: "phone_2", Outcome: model.OfferStatusDefaulted}))
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 21, UserID: 4, ItemName: "phone_2", Outcome: model.OfferStatusPaid}))
+	// phone_3 is defaulted by both buyers
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 20, UserID: 3, ItemName: "phone_3", Outcome: model.OfferStatusDefaulted}))
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 21, UserID: 4, ItemName: "phone_3", Outcome: model.OfferStatusDefaulted}))
+
+	assert.Equal(t, model.SettlementStatusSettled, getResult(t, s, "phone_1").SettlementStatus)
+
+	second := getResult(t, s, "phone_2")
+	assert.Equal(t, model.SettlementStatusPaid, second.SettlementStatus)
+	assert.Equal(t, 4, second.UserID)
+	assert.Equal(t, model.Money(2500), second.PricePaid)
+	assert.Equal(t, []model.Offer{
+		{UserID: 3, Price: 2500, Timestamp: 20, Status: model.OfferStatusDefaulted},
+		{UserID: 4, Price: 2500, Timestamp: 20, Status: model.OfferStatusPaid},
+	}, second.Offers)
+
+	third := getResult(t, s, "phone_3")
+	assert.Equal(t, model.OrderStatusUnsold, third.Status)
+	assert.Equal(t, model.SettlementStatusDefaulted, third.SettlementStatus)
+	assert.Equal(t, 0, third.UserID)
+	assert.Len(t, third.Offers, 2)
+}
+
+func testDeposits(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	orders := GenerateOrders(1)
+	orders[0].Item.DepositRate = 1000 // 10% of the reserve price
+
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	err := s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 3000})
+	assert.ErrorIs(t, err, model.ErrDepositRequired)
+
+	assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", Amount: 100}))
+	assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", Amount: 100}))
+	assert.NoError(t, s.PostDeposit(context.TODO(), model.DepositCommand{Timestamp: 12, UserID: 4, ItemName: "phone_1", Amount: 200}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 3, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 14, UserID: 4, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+
+	// the deposit of the winner is held until the payment
+	assert.Equal(t, []model.Deposit{
+		{UserID: 3, Amount: 200, Timestamp: 11, Status: model.DepositStatusHeld},
+		{UserID: 4, Amount: 200, Timestamp: 12, Status: model.DepositStatusRefunded, ResolvedTime: 15},
+	}, getResult(t, s, "phone_1").Deposits)
+
+	assert.NoError(t, s.RecordPayment(context.TODO(), model.PaymentCommand{Timestamp: 20, UserID: 3, ItemName: "phone_1", Outcome: model.OfferStatusDefaulted}))
+	assert.Equal(t, model.DepositStatusForfeited, getResult(t, s, "phone_1").Deposits[0].Status)
+}
+
+func testQueries(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	orders := GenerateOrders(3)
+	orders[1].SellerID = 7
+
+	s := newStorage(t)
+	for _, order := range orders {
+		assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	}
+	bids := []model.BidCommand{
+		{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500},
+		{Timestamp: 13, UserID: 3, ItemName: "phone_2", BidAmount: 1500},
+		{Timestamp: 14, UserID: 4, ItemName: "phone_2", BidAmount: 2500},
+		{Timestamp: 15, UserID: 5, ItemName: "phone_2", BidAmount: 1800},
+	}
+	for _, bid := range bids {
+		assert.NoError(t, s.BidOrder(context.TODO(), bid))
+	}
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 16, UserID: 5, ItemName: "phone_2"}))
+
+	view, err := s.GetOrder(context.TODO(), "phone_2")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, view.LeaderID)
+	assert.Equal(t, model.Money(2500), view.Price)
+	assert.Equal(t, 2, view.Statistics.TotalBidCount)
+	_, err = s.GetOrder(context.TODO(), "tv_1")
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	// phone_1 is closed, open auctions are listed in the close order
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 16))
+	view, err = s.GetOrder(context.TODO(), "phone_1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusSold, view.Order.Status)
+	assert.Equal(t, 3, view.LeaderID)
+	assert.Equal(t, model.Money(2000), view.Price)
+
+	reserveMet := true
+	testCases := []struct {
+		name   string
+		filter model.AuctionFilter
+		exp    []string
+	}{
+		{name: "all", exp: []string{"phone_2", "phone_3"}},
+		{name: "closing_before", filter: model.AuctionFilter{ClosingBefore: 25}, exp: []string{"phone_2"}},
+		{name: "seller", filter: model.AuctionFilter{SellerID: 7}, exp: []string{"phone_2"}},
+		{name: "reserve_met", filter: model.AuctionFilter{ReserveMet: &reserveMet}, exp: []string{"phone_2"}},
+		{name: "none", filter: model.AuctionFilter{ClosingBefore: 20}, exp: []string{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			views, err := s.ListOpenAuctions(context.TODO(), tc.filter)
+			assert.NoError(t, err)
+			names := make([]string, 0, len(views))
+			for _, view := range views {
+				names = append(names, view.Order.Item.Name)
+			}
+			assert.Equal(t, tc.exp, names)
+		})
+	}
+
+	// bid history is paginated, retracted bids are marked
+	history, err := s.GetBidHistory(context.TODO(), "phone_2", model.Page{Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, history.Total)
+	require.Len(t, history.Bids, 1)
+	assert.Equal(t, 4, history.Bids[0].UserID)
+	history, err = s.GetBidHistory(context.TODO(), "phone_2", model.Page{Offset: 2})
+	assert.NoError(t, err)
+	require.Len(t, history.Bids, 1)
+	assert.True(t, history.Bids[0].Retracted)
+	_, err = s.GetBidHistory(context.TODO(), "phone_2", model.Page{Limit: -1})
+	assert.ErrorIs(t, err, model.ErrInvalidData)
+}
+
+func testPointInTime(t *testing.T, newStorage func(t *testing.T) auction.Storage) {
+	orders := GenerateOrders(1)
+	admin := 99
+
+	s := newStorage(t)
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 11, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 4, ItemName: "phone_1", BidAmount: 3000}))
+	assert.NoError(t, s.RetractBid(context.TODO(), model.RetractCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1"}))
+	assert.NoError(t, s.ExtendAuction(context.TODO(), model.AdminCommand{Timestamp: 14, UserID: admin, ItemName: "phone_1", CloseTime: 18}))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 17, UserID: 5, ItemName: "phone_1", BidAmount: 2200}))
+	assert.NoError(t, s.FinishExpiredAuctions(context.TODO(), 19))
+
+	testCases := []struct {
+		name      string
+		timestamp int64
+		exp       model.AuctionState
+		err       error
+	}{
+		{
+			name:      "created",
+			timestamp: 10,
+			exp:       model.AuctionState{Timestamp: 10, Item: "phone_1", Status: model.OrderStatusInit},
+		},
+		{
+			name:      "outbid",
+			timestamp: 12,
+			exp:       model.AuctionState{Timestamp: 12, Item: "phone_1", Status: model.OrderStatusInit, LeaderID: 4, Price: 3000, BidCount: 2},
+		},
+		{
+			name:      "retracted",
+			timestamp: 13,
+			exp:       model.AuctionState{Timestamp: 13, Item: "phone_1", Status: model.OrderStatusInit, LeaderID: 3, Price: 2500, BidCount: 1},
+		},
+		{
+			name:      "extended",
+			timestamp: 16,
+			exp:       model.AuctionState{Timestamp: 16, Item: "phone_1", Status: model.OrderStatusInit, LeaderID: 3, Price: 2500, BidCount: 1},
+		},
+		{
+			name:      "sold",
+			timestamp: 19,
+			exp:       model.AuctionState{Timestamp: 19, Item: "phone_1", Status: model.OrderStatusSold, LeaderID: 3, Price: 2200, BidCount: 2},
+		},
+		{
+			name:      "err/not_created",
+			timestamp: 9,
+			err:       model.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state, err := s.GetOrderAt(context.TODO(), "phone_1", tc.timestamp)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.exp, state)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+// Package writethrough provides the auction storage persisted to the durable store.
+// Auction rules are applied by the in-memory storage, orders changed by every call and ledger events
+// it posted are written to the store in one transaction, the state is loaded back from the store on start.
+package writethrough
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/storage/inmemory"
+)
+
+// Store is the durable store of orders and ledger events
+type Store interface {
+	// Load reads all saved orders and ledger events in the order they were saved
+	Load(ctx context.Context) ([]inmemory.OrderState, []model.LedgerEvent, error)
+	// Save writes changed orders and new ledger events atomically: all of them or none
+	Save(ctx context.Context, changes []inmemory.OrderState, events []model.LedgerEvent) error
+}
+
+// Storage applies auction rules in memory and writes every change through to the store.
+type Storage struct {
+	mx sync.Mutex
+
+	store  Store
+	engine *inmemory.Storage
+}
+
+// New loads saved orders from the store and posts saved ledger events to the ledger set by options.
+// Options configure auction policies the same way as for the in-memory storage
+func New(ctx context.Context, store Store, opts ...inmemory.Option) (*Storage, error) {
+	s := &Storage{
+		store:  store,
+		engine: inmemory.New(append(opts, inmemory.WithChangeTracking())...),
+	}
+	if err := s.load(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load auctions: %w", err)
+	}
+
+	return s, nil
+}
+
+// CreateOrder method stores order and initiate first auction state
+func (s *Storage) CreateOrder(ctx context.Context, order model.Order) error {
+	return s.apply(ctx, func() error {
+		return s.engine.CreateOrder(ctx, order)
+	})
+}
+
+// BidOrder method checks bid value for the order and saves the bid to the history data
+func (s *Storage) BidOrder(ctx context.Context, bid model.BidCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.BidOrder(ctx, bid)
+	})
+}
+
+// PostDeposit method saves the deposit of the bidder to the opened order
+func (s *Storage) PostDeposit(ctx context.Context, deposit model.DepositCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.PostDeposit(ctx, deposit)
+	})
+}
+
+// CancelOrder method withdraws the order by its seller while the reserve price is not met
+func (s *Storage) CancelOrder(ctx context.Context, cancel model.CancelCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.CancelOrder(ctx, cancel)
+	})
+}
+
+// RetractBid method withdraws the latest bid of the user
+func (s *Storage) RetractBid(ctx context.Context, retract model.RetractCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.RetractBid(ctx, retract)
+	})
+}
+
+// LowerReserve method lowers the reserve price of the opened order by its seller
+func (s *Storage) LowerReserve(ctx context.Context, reserve model.ReserveCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.LowerReserve(ctx, reserve)
+	})
+}
+
+// RecordPayment method saves the payment outcome of the current buyer of the sold order
+func (s *Storage) RecordPayment(ctx context.Context, payment model.PaymentCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.RecordPayment(ctx, payment)
+	})
+}
+
+// RefundOrder method returns the payment to the buyer of the paid order
+func (s *Storage) RefundOrder(ctx context.Context, cmd model.AdminCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.RefundOrder(ctx, cmd)
+	})
+}
+
+// SettleOrder method pays out the paid order to the seller
+func (s *Storage) SettleOrder(ctx context.Context, cmd model.AdminCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.SettleOrder(ctx, cmd)
+	})
+}
+
+// ExtendAuction method moves the close time of the opened order to the later time
+func (s *Storage) ExtendAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.ExtendAuction(ctx, cmd)
+	})
+}
+
+// ForceCloseAuction method closes the opened order before its close time
+func (s *Storage) ForceCloseAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.ForceCloseAuction(ctx, cmd)
+	})
+}
+
+// VoidAuction method invalidates the order
+func (s *Storage) VoidAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.VoidAuction(ctx, cmd)
+	})
+}
+
+// ReopenAuction method opens the closed order again until the new close time
+func (s *Storage) ReopenAuction(ctx context.Context, cmd model.AdminCommand) error {
+	return s.apply(ctx, func() error {
+		return s.engine.ReopenAuction(ctx, cmd)
+	})
+}
+
+// FinishExpiredAuctions method finishes auctions that expired by time
+func (s *Storage) FinishExpiredAuctions(ctx context.Context, timestamp int64) error {
+	return s.apply(ctx, func() error {
+		return s.engine.FinishExpiredAuctions(ctx, timestamp)
+	})
+}
+
+// FinishExpiredOrders method finishes the given orders that expired by time
+func (s *Storage) FinishExpiredOrders(ctx context.Context, names []string, timestamp int64) error {
+	return s.apply(ctx, func() error {
+		return s.engine.FinishExpiredOrders(ctx, names, timestamp)
+	})
+}
+
+// FinishAllAuctions method finishes unfinished auctions
+func (s *Storage) FinishAllAuctions(ctx context.Context) error {
+	return s.apply(ctx, func() error {
+		return s.engine.FinishAllAuctions(ctx)
+	})
+}
+
+// GetAuctionResults provides results of all auctions
+func (s *Storage) GetAuctionResults(ctx context.Context) ([]model.ActionResult, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.GetAuctionResults(ctx)
+}
+
+// GetOrder provides the order with its current leader and price
+func (s *Storage) GetOrder(ctx context.Context, itemName string) (model.AuctionView, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.GetOrder(ctx, itemName)
+}
+
+// ListOpenAuctions provides open orders matching the filter in the close order
+func (s *Storage) ListOpenAuctions(ctx context.Context, filter model.AuctionFilter) ([]model.AuctionView, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.ListOpenAuctions(ctx, filter)
+}
+
+// GetBidHistory provides the page of bids on the order in the order they were placed
+func (s *Storage) GetBidHistory(ctx context.Context, itemName string, page model.Page) (model.BidHistory, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.GetBidHistory(ctx, itemName, page)
+}
+
+// GetOrderAt provides the state of the order as of the time
+func (s *Storage) GetOrderAt(ctx context.Context, itemName string, timestamp int64) (model.AuctionState, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.engine.GetOrderAt(ctx, itemName, timestamp)
+}
+
+// apply runs the operation and saves orders it changed and ledger events it posted once the operation succeeds.
+// Ledger events are posted to the ledger after they are saved. If the operation fails halfway or its changes
+// can't be saved, the state is loaded back from the store, so neither the state nor the ledger keep the changes
+func (s *Storage) apply(ctx context.Context, operation func() error) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	opErr := operation()
+
+	changes := s.engine.ChangedOrders()
+	events := s.engine.ChangedLedgerEvents()
+	if len(changes) == 0 && len(events) == 0 {
+		return opErr
+	}
+	if opErr != nil {
+		if loadErr := s.reload(ctx); loadErr != nil {
+			return fmt.Errorf("%w, failed to load auctions: %v", opErr, loadErr)
+		}
+		return opErr
+	}
+	if err := s.store.Save(ctx, changes, events); err != nil {
+		if loadErr := s.reload(ctx); loadErr != nil {
+			return fmt.Errorf("failed to save changes: %w, failed to load auctions: %v", err, loadErr)
+		}
+		return fmt.Errorf("failed to save changes: %w", err)
+	}
+
+	return s.engine.PostLedgerEvents(events)
+}
+
+// load restores the in-memory state and the ledger from the store
+func (s *Storage) load(ctx context.Context) error {
+	states, events, err := s.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.engine.Restore(states); err != nil {
+		return err
+	}
+
+	return s.engine.PostLedgerEvents(events)
+}
+
+// reload restores the in-memory state from the store after the operation failed or its changes failed to be saved.
+// The ledger is kept as it is, since ledger events not saved are not posted
+func (s *Storage) reload(ctx context.Context) error {
+	states, _, err := s.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.engine.Restore(states)
+}
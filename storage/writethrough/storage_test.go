@@ -0,0 +1,134 @@
+package writethrough
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/senseyman/auction-house/model"
+	"github.com/senseyman/auction-house/service/auction"
+	"github.com/senseyman/auction-house/service/ledger"
+	"github.com/senseyman/auction-house/storage/inmemory"
+	"github.com/senseyman/auction-house/storage/storagetest"
+)
+
+var errStore = errors.New("store is not available")
+
+// testStore keeps saved orders and ledger events in memory and fails saving when broken
+type testStore struct {
+	states map[string]inmemory.OrderState
+	events []model.LedgerEvent
+	broken bool
+}
+
+func (st *testStore) Load(context.Context) ([]inmemory.OrderState, []model.LedgerEvent, error) {
+	res := make([]inmemory.OrderState, 0, len(st.states))
+	for _, state := range st.states {
+		res = append(res, state)
+	}
+
+	return res, st.events, nil
+}
+
+func (st *testStore) Save(_ context.Context, changes []inmemory.OrderState, events []model.LedgerEvent) error {
+	if st.broken {
+		return errStore
+	}
+	for _, state := range changes {
+		st.states[state.Order.Item.Name] = state
+	}
+	st.events = append(st.events, events...)
+
+	return nil
+}
+
+func TestStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) auction.Storage {
+		s, err := New(context.TODO(), &testStore{states: make(map[string]inmemory.OrderState)})
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestStorage_SaveError(t *testing.T) {
+	orders := storagetest.GenerateOrders(1)
+	store := &testStore{states: make(map[string]inmemory.OrderState)}
+	ledgerService := ledger.New()
+
+	s, err := New(context.TODO(), store, inmemory.WithLedger(ledgerService))
+	require.NoError(t, err)
+	assert.NoError(t, s.CreateOrder(context.TODO(), orders[0]))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500}))
+
+	// the bid and the sale not saved are rolled back, the sale is not posted to the ledger
+	store.broken = true
+	err = s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 13, UserID: 4, ItemName: "phone_1", BidAmount: 3000})
+	assert.ErrorIs(t, err, errStore)
+	err = s.FinishAllAuctions(context.TODO())
+	assert.ErrorIs(t, err, errStore)
+	assert.Empty(t, ledgerService.Entries())
+
+	store.broken = false
+	assert.NoError(t, s.FinishAllAuctions(context.TODO()))
+	results, err := s.GetAuctionResults(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results[0].UserID)
+	assert.Equal(t, 1, results[0].Statistics.TotalBidCount)
+	assert.Equal(t, model.OrderStatusSold, store.states["phone_1"].Order.Status)
+	assert.Len(t, ledgerService.Entries(), 1)
+	assert.Equal(t, store.events, []model.LedgerEvent{{
+		Type:      model.LedgerEventSale,
+		Timestamp: 15,
+		Item:      "phone_1",
+		BuyerID:   3,
+		Price:     2000,
+	}})
+}
+
+// testConverter converts money by fixed rates to the reporting currency
+type testConverter map[model.Currency]int64
+
+func (c testConverter) Convert(amount model.Money, from, to model.Currency, _ int64) (model.Money, error) {
+	fromRate, ok := c[from]
+	if !ok {
+		return 0, model.ErrUnsupportedCurrency
+	}
+	toRate, ok := c[to]
+	if !ok {
+		return 0, model.ErrUnsupportedCurrency
+	}
+
+	return amount.MulDiv(fromRate, toRate), nil
+}
+
+func TestStorage_OperationError(t *testing.T) {
+	order := storagetest.GenerateOrders(1)[0]
+	order.Item.Currency = "EUR"
+	rates := testConverter{"": 100, "EUR": 110}
+	store := &testStore{states: make(map[string]inmemory.OrderState)}
+	ledgerService := ledger.New()
+
+	s, err := New(context.TODO(), store, inmemory.WithCurrencyConverter(rates), inmemory.WithLedger(ledgerService))
+	require.NoError(t, err)
+	assert.NoError(t, s.CreateOrder(context.TODO(), order))
+	assert.NoError(t, s.BidOrder(context.TODO(), model.BidCommand{Timestamp: 12, UserID: 3, ItemName: "phone_1", BidAmount: 2500, Currency: "EUR"}))
+	assert.NoError(t, s.ForceCloseAuction(context.TODO(), model.AdminCommand{Timestamp: 13, UserID: 99, ItemName: "phone_1"}))
+	assert.Len(t, ledgerService.Entries(), 1)
+
+	// the reopened auction fails to count the exposure of the leader after the sale is reversed,
+	// nothing of the failed operation is kept or saved
+	delete(rates, "EUR")
+	err = s.ReopenAuction(context.TODO(), model.AdminCommand{Timestamp: 14, UserID: 99, ItemName: "phone_1", CloseTime: 30})
+	assert.ErrorIs(t, err, model.ErrUnsupportedCurrency)
+
+	view, err := s.GetOrder(context.TODO(), "phone_1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusSold, view.Order.Status)
+	assert.Equal(t, model.OrderStatusSold, store.states["phone_1"].Order.Status)
+	assert.Len(t, store.states["phone_1"].History, 3)
+	assert.Len(t, store.events, 1)
+	assert.Len(t, ledgerService.Entries(), 1)
+}